@@ -1,7 +1,10 @@
 package gorm
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"reflect"
 	"strings"
 )
 
@@ -13,43 +16,68 @@ func BeforeCreate(scope *Scope) {
 func UpdateTimeStampWhenCreate(scope *Scope) {
 	if !scope.HasError() {
 		now := NowFunc()
-		scope.SetColumn("Created_At", now)
-		scope.SetColumn("Updated_At", now)
+		scope.forEachElement(func(scope *Scope) {
+			scope.SetColumn("Created_At", now)
+			scope.SetColumn("Updated_At", now)
+			scope.setAutoTime("AUTOCREATETIME", now)
+			scope.setAutoTime("AUTOUPDATETIME", now)
+		})
 	}
 }
 
+// createFields returns the fields should be inserted for scope's value, and the columns should be reloaded after create as they will be filled with default values
+func createFields(scope *Scope) (createFields []*Field, reloadColumns []string) {
+	fields := scope.Fields()
+	for _, structField := range scope.GetStructFields() {
+		field := fields[structField.DBName]
+		if field.StructField != structField {
+			continue
+		}
+
+		if scope.changeableField(field) {
+			if field.IsNormal {
+				if !field.IsCreatable {
+					continue
+				}
+
+				if !field.IsPrimaryKey || (field.IsPrimaryKey && !field.IsBlank) {
+					if !field.IsBlank || !field.HasDefaultValue {
+						createFields = append(createFields, field)
+					} else if field.HasDefaultValue {
+						reloadColumns = append(reloadColumns, field.DBName)
+					}
+				}
+			} else if relationship := field.Relationship; relationship != nil && relationship.Kind == "belongs_to" {
+				for _, dbName := range relationship.ForeignDBNames {
+					if relationField := fields[dbName]; !scope.changeableField(relationField) {
+						createFields = append(createFields, relationField)
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
 func Create(scope *Scope) {
+	if scope.IndirectValue().Kind() == reflect.Slice {
+		createInBatches(scope)
+		return
+	}
+
 	defer scope.Trace(NowFunc())
 
 	if !scope.HasError() {
 		// set create sql
 		var sqls, columns []string
-		fields := scope.Fields()
+		fields, reloadColumns := createFields(scope)
 		for _, field := range fields {
-			if scope.changeableField(field) {
-				if field.IsNormal {
-					if !field.IsPrimaryKey || (field.IsPrimaryKey && !field.IsBlank) {
-						if !field.IsBlank || !field.HasDefaultValue {
-							columns = append(columns, scope.Quote(field.DBName))
-							sqls = append(sqls, scope.AddToVars(field.Field.Interface()))
-						} else if field.HasDefaultValue {
-							var hasDefaultValueColumns []string
-							if oldHasDefaultValueColumns, ok := scope.InstanceGet("gorm:force_reload_after_create_attrs"); ok {
-								hasDefaultValueColumns = oldHasDefaultValueColumns.([]string)
-							}
-							hasDefaultValueColumns = append(hasDefaultValueColumns, field.DBName)
-							scope.InstanceSet("gorm:force_reload_after_create_attrs", hasDefaultValueColumns)
-						}
-					}
-				} else if relationship := field.Relationship; relationship != nil && relationship.Kind == "belongs_to" {
-					for _, dbName := range relationship.ForeignDBNames {
-						if relationField := fields[dbName]; !scope.changeableField(relationField) {
-							columns = append(columns, scope.Quote(relationField.DBName))
-							sqls = append(sqls, scope.AddToVars(relationField.Field.Interface()))
-						}
-					}
-				}
-			}
+			columns = append(columns, field.DBName)
+			sqls = append(sqls, scope.AddToVars(field.Field.Interface()))
+		}
+
+		if len(reloadColumns) > 0 {
+			scope.InstanceSet("gorm:force_reload_after_create_attrs", reloadColumns)
 		}
 
 		returningKey := "*"
@@ -64,47 +92,265 @@ func Create(scope *Scope) {
 				scope.Dialect().ReturningStr(scope.QuotedTableName(), returningKey),
 			))
 		} else {
-			scope.Raw(fmt.Sprintf(
-				"INSERT INTO %v (%v) VALUES (%v) %v",
-				scope.QuotedTableName(),
-				strings.Join(columns, ","),
-				strings.Join(sqls, ","),
-				scope.Dialect().ReturningStr(scope.QuotedTableName(), returningKey),
-			))
+			scope.Raw(insertSql(scope, columns, []string{fmt.Sprintf("(%v)", strings.Join(sqls, ","))}, returningKey))
+		}
+
+		if scope.HasError() {
+			return
 		}
 
 		// execute create sql
-		if scope.Dialect().SupportLastInsertId() {
-			if result, err := scope.SqlDB().Exec(scope.Sql, scope.SqlVars...); scope.Err(err) == nil {
-				id, err := result.LastInsertId()
-				if scope.Err(err) == nil {
+		scope.instrument(OperationCreate, func(ctx context.Context) (err error) {
+			var result sql.Result
+			if onConflict, ok := upsertOption(scope); ok {
+				if result, err = scope.writeDB().ExecContext(ctx, scope.Sql, scope.SqlVars...); scope.Err(err) == nil {
 					scope.db.RowsAffected, _ = result.RowsAffected()
 					if primaryField != nil && primaryField.IsBlank {
-						scope.Err(scope.SetColumn(primaryField, id))
+						// the conflicted record's primary key is reloaded, as it's not returned when the record is kept or updated,
+						// LastInsertId is only used without conflict columns, which is the conflicted record's on mysql, and stale on others if nothing inserted
+						if len(onConflict.Columns) > 0 {
+							reloadPrimaryKeyByConflictColumns(scope, onConflict)
+						} else if id, err := result.LastInsertId(); err == nil && id > 0 && scope.db.RowsAffected > 0 {
+							scope.Err(scope.SetColumn(primaryField, id))
+						}
 					}
 				}
-			}
-		} else {
-			if primaryField == nil {
-				if results, err := scope.SqlDB().Exec(scope.Sql, scope.SqlVars...); err == nil {
-					scope.db.RowsAffected, _ = results.RowsAffected()
-				} else {
-					scope.Err(err)
+			} else if scope.Dialect().SupportLastInsertId() {
+				if result, err = scope.writeDB().ExecContext(ctx, scope.Sql, scope.SqlVars...); scope.Err(err) == nil {
+					var id int64
+					if id, err = result.LastInsertId(); scope.Err(err) == nil {
+						scope.db.RowsAffected, _ = result.RowsAffected()
+						if primaryField != nil && primaryField.IsBlank {
+							scope.Err(scope.SetColumn(primaryField, id))
+						}
+					}
 				}
 			} else {
-				if err := scope.Err(scope.SqlDB().QueryRow(scope.Sql, scope.SqlVars...).Scan(primaryField.Field.Addr().Interface())); err == nil {
-					scope.db.RowsAffected = 1
+				if primaryField == nil {
+					if result, err = scope.writeDB().ExecContext(ctx, scope.Sql, scope.SqlVars...); scope.Err(err) == nil {
+						scope.db.RowsAffected, _ = result.RowsAffected()
+					}
 				} else {
-					scope.Err(err)
+					if err = scope.Err(scope.writeDB().QueryRowContext(ctx, scope.Sql, scope.SqlVars...).Scan(primaryField.Field.Addr().Interface())); err == nil {
+						scope.db.RowsAffected = 1
+					}
+				}
+			}
+			return
+		})
+	}
+}
+
+// createInBatches insert a slice of records with multi-row INSERT statements, records are split into batches by
+// setting `gorm:batch_size`, and consecutive records that insert different columns are inserted separately
+func createInBatches(scope *Scope) {
+	if scope.HasError() {
+		return
+	}
+
+	var (
+		batchSize        = scope.IndirectValue().Len()
+		allReloadColumns []string
+		batch            []*Scope
+		batchColumns     []string
+	)
+
+	if size, ok := scope.Get("gorm:batch_size"); ok {
+		if size, ok := size.(int); ok && size > 0 {
+			batchSize = size
+		}
+	}
+
+	scope.forEachElement(func(elemScope *Scope) {
+		fields, reloadColumns := createFields(elemScope)
+		var columns []string
+		for _, field := range fields {
+			columns = append(columns, field.DBName)
+		}
+
+		for _, column := range reloadColumns {
+			if !strInSlice(column, allReloadColumns) {
+				allReloadColumns = append(allReloadColumns, column)
+			}
+		}
+
+		if len(batch) > 0 && (len(batch) >= batchSize || len(columns) == 0 || strings.Join(columns, ",") != strings.Join(batchColumns, ",")) {
+			createBatch(scope, batch, batchColumns)
+			batch = nil
+		}
+		batch, batchColumns = append(batch, elemScope), columns
+	})
+
+	if len(batch) > 0 {
+		createBatch(scope, batch, batchColumns)
+	}
+
+	if len(allReloadColumns) > 0 {
+		scope.InstanceSet("gorm:force_reload_after_create_attrs", allReloadColumns)
+	}
+}
+
+func createBatch(scope *Scope, batch []*Scope, columns []string) {
+	if scope.HasError() {
+		return
+	}
+
+	// primary keys are filled with the last insert id and the rows' offsets, which requires consecutive ids,
+	// otherwise the records are inserted one by one to get their own ids
+	if primaryField := scope.PrimaryField(); len(batch) > 1 && primaryField != nil && !strInSlice(primaryField.DBName, columns) {
+		if _, ok := upsertOption(scope); !ok && scope.Dialect().SupportLastInsertId() && !scope.Dialect().SupportConsecutiveInsertIds() {
+			for _, elemScope := range batch {
+				createBatch(scope, []*Scope{elemScope}, columns)
+			}
+			return
+		}
+	}
+
+	defer scope.Trace(NowFunc())
+	scope.SqlVars = nil
+
+	var values []string
+	for _, elemScope := range batch {
+		var sqls []string
+		for _, column := range columns {
+			sqls = append(sqls, scope.AddToVars(elemScope.Fields()[column].Field.Interface()))
+		}
+		values = append(values, fmt.Sprintf("(%v)", strings.Join(sqls, ",")))
+	}
+
+	returningKey := "*"
+	primaryField := scope.PrimaryField()
+	if primaryField != nil {
+		returningKey = scope.Quote(primaryField.DBName)
+	}
+
+	if len(columns) == 0 {
+		scope.Raw(fmt.Sprintf("INSERT INTO %v DEFAULT VALUES %v",
+			scope.QuotedTableName(),
+			scope.Dialect().ReturningStr(scope.QuotedTableName(), returningKey),
+		))
+	} else {
+		scope.Raw(insertSql(scope, columns, values, returningKey))
+	}
+
+	if scope.HasError() {
+		return
+	}
+
+	// execute create sql
+	scope.instrument(OperationCreate, func(ctx context.Context) (err error) {
+		var result sql.Result
+		if _, ok := upsertOption(scope); ok {
+			if result, err = scope.writeDB().ExecContext(ctx, scope.Sql, scope.SqlVars...); scope.Err(err) == nil {
+				// primary keys of records upserted in batch aren't filled, as updated and kept records return no ids,
+				// query them by the conflict columns if required
+				count, _ := result.RowsAffected()
+				scope.db.RowsAffected += count
+			}
+		} else if scope.Dialect().SupportLastInsertId() || primaryField == nil {
+			if result, err = scope.writeDB().ExecContext(ctx, scope.Sql, scope.SqlVars...); scope.Err(err) == nil {
+				count, _ := result.RowsAffected()
+				scope.db.RowsAffected += count
+
+				if primaryField != nil && !strInSlice(primaryField.DBName, columns) {
+					var id int64
+					if id, err = result.LastInsertId(); scope.Err(err) == nil {
+						id = scope.Dialect().FirstInsertId(id, int64(len(batch)))
+						for idx, elemScope := range batch {
+							scope.Err(elemScope.SetColumn(elemScope.PrimaryField(), id+int64(idx)))
+						}
+					}
+				}
+			}
+		} else {
+			var rows *sql.Rows
+			if rows, err = scope.writeDB().QueryContext(ctx, scope.Sql, scope.SqlVars...); scope.Err(err) == nil {
+				defer rows.Close()
+				for idx := 0; rows.Next() && idx < len(batch); idx++ {
+					if scanErr := scope.Err(rows.Scan(batch[idx].PrimaryField().Field.Addr().Interface())); scanErr == nil {
+						scope.db.RowsAffected++
+					} else {
+						err = scanErr
+					}
+				}
+				if rowsErr := scope.Err(rows.Err()); rowsErr != nil {
+					err = rowsErr
+				}
+			}
+		}
+		return
+	})
+}
+
+// upsertOption returns the upsert option set with `gorm:on_conflict`
+func upsertOption(scope *Scope) (OnConflict, bool) {
+	if value, ok := scope.Get("gorm:on_conflict"); ok {
+		switch onConflict := value.(type) {
+		case OnConflict:
+			return onConflict, true
+		case *OnConflict:
+			return *onConflict, onConflict != nil
+		}
+	}
+	return OnConflict{}, false
+}
+
+// insertSql build the INSERT statement for columns and rows of values, which will be an upsert if `gorm:on_conflict` is set
+func insertSql(scope *Scope, columns []string, values []string, returningKey string) string {
+	if onConflict, ok := upsertOption(scope); ok {
+		if !onConflict.DoNothing && len(onConflict.DoUpdates) == 0 {
+			// fields not allowed to be updated are kept as they are when conflicted
+			for _, column := range onConflict.UpdateColumns(columns) {
+				if field, ok := scope.FieldByName(column); !ok || field.IsUpdatable {
+					onConflict.DoUpdates = append(onConflict.DoUpdates, column)
 				}
 			}
+			onConflict.DoNothing = len(onConflict.DoUpdates) == 0
 		}
+		var primaryKey string
+		if primaryField := scope.PrimaryField(); primaryField != nil {
+			primaryKey = primaryField.DBName
+		}
+
+		sql, err := scope.Dialect().UpsertSql(scope.QuotedTableName(), primaryKey, columns, values, onConflict)
+		scope.Err(err)
+		return sql
+	}
+
+	var quotedColumns []string
+	for _, column := range columns {
+		quotedColumns = append(quotedColumns, scope.Quote(column))
+	}
+
+	return fmt.Sprintf(
+		"INSERT INTO %v (%v) VALUES %v %v",
+		scope.QuotedTableName(),
+		strings.Join(quotedColumns, ","),
+		strings.Join(values, ","),
+		scope.Dialect().ReturningStr(scope.QuotedTableName(), returningKey),
+	)
+}
+
+// reloadPrimaryKeyByConflictColumns fill the primary key of an upserted record, whose row may have been updated instead of inserted
+func reloadPrimaryKeyByConflictColumns(scope *Scope, onConflict OnConflict) {
+	primaryField := scope.PrimaryField()
+	query := scope.NewDB().Table(scope.TableName()).Select(scope.Quote(primaryField.DBName))
+	for _, column := range onConflict.ConflictColumns() {
+		if field, ok := scope.FieldByName(column); ok {
+			query = query.Where(fmt.Sprintf("%v = ?", scope.Quote(field.DBName)), field.Field.Interface())
+		}
+	}
+
+	if scope.Err(query.Row().Scan(primaryField.Field.Addr().Interface())) == nil {
+		primaryField.IsBlank = isBlank(primaryField.Field)
 	}
 }
 
 func ForceReloadAfterCreate(scope *Scope) {
 	if columns, ok := scope.InstanceGet("gorm:force_reload_after_create_attrs"); ok {
-		scope.DB().New().Select(columns.([]string)).First(scope.Value)
+		scope.forEachElement(func(elemScope *Scope) {
+			scope.DB().New().Select(columns.([]string)).First(elemScope.Value)
+		})
 	}
 }
 
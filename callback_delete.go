@@ -8,18 +8,19 @@ func BeforeDelete(scope *Scope) {
 
 func Delete(scope *Scope) {
 	if !scope.HasError() {
-		if !scope.Search.Unscoped && scope.HasColumn("Deleted_At") {
+		if field := scope.softDeleteField(); !scope.Search.Unscoped && field != nil {
 			scope.Raw(
-				fmt.Sprintf("UPDATE %v SET deleted_at=%v %v",
+				fmt.Sprintf("UPDATE %v SET %v=%v %v",
 					scope.QuotedTableName(),
-					scope.AddToVars(NowFunc()),
+					scope.Quote(field.DBName),
+					scope.AddToVars(softDeletedValue(field)),
 					scope.CombinedConditionSql(),
 				))
 		} else {
 			scope.Raw(fmt.Sprintf("DELETE FROM %v %v", scope.QuotedTableName(), scope.CombinedConditionSql()))
 		}
 
-		scope.Exec()
+		scope.exec(OperationDelete)
 	}
 }
 
@@ -1,6 +1,7 @@
 package gorm
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -27,7 +28,12 @@ func Query(scope *Scope) {
 		dest = reflect.Indirect(reflect.ValueOf(value))
 	}
 
-	if kind := dest.Kind(); kind == reflect.Slice {
+	if !dest.CanAddr() {
+		scope.Err(errors.New("unsupported destination, should be pointer"))
+		return
+	}
+
+	if kind := dest.Kind(); kind == reflect.Slice && dest.Type().Elem().Kind() != reflect.Uint8 {
 		isSlice = true
 		destType = dest.Type().Elem()
 		dest.Set(reflect.MakeSlice(dest.Type(), 0, 0))
@@ -36,74 +42,44 @@ func Query(scope *Scope) {
 			isPtr = true
 			destType = destType.Elem()
 		}
-	} else if kind != reflect.Struct {
-		scope.Err(errors.New("unsupported destination, should be slice or struct"))
-		return
 	}
 
 	scope.prepareQuerySql()
 
 	if !scope.HasError() {
-		rows, err := scope.SqlDB().Query(scope.Sql, scope.SqlVars...)
-		scope.db.RowsAffected = 0
+		scope.instrument(OperationQuery, func(ctx context.Context) error {
+			rows, err := scope.readDB().QueryContext(ctx, scope.Sql, scope.SqlVars...)
+			scope.db.RowsAffected = 0
 
-		if scope.Err(err) != nil {
-			return
-		}
-		defer rows.Close()
-
-		columns, _ := rows.Columns()
-		for rows.Next() {
-			scope.db.RowsAffected++
-
-			anyRecordFound = true
-			elem := dest
-			if isSlice {
-				elem = reflect.New(destType).Elem()
+			if scope.Err(err) != nil {
+				return err
 			}
+			defer rows.Close()
 
-			var values = make([]interface{}, len(columns))
-
-			fields := scope.New(elem.Addr().Interface()).Fields()
+			columns, _ := rows.Columns()
+			for rows.Next() {
+				scope.db.RowsAffected++
 
-			for index, column := range columns {
-				if field, ok := fields[column]; ok {
-					if field.Field.Kind() == reflect.Ptr {
-						values[index] = field.Field.Addr().Interface()
-					} else {
-						reflectValue := reflect.New(reflect.PtrTo(field.Struct.Type))
-						reflectValue.Elem().Set(field.Field.Addr())
-						values[index] = reflectValue.Interface()
-					}
-				} else {
-					var value interface{}
-					values[index] = &value
+				anyRecordFound = true
+				elem := dest
+				if isSlice {
+					elem = reflect.New(destType).Elem()
 				}
-			}
 
-			scope.Err(rows.Scan(values...))
+				scope.Err(scope.scanRow(rows, columns, elem))
 
-			for index, column := range columns {
-				value := values[index]
-				if field, ok := fields[column]; ok {
-					if field.Field.Kind() == reflect.Ptr {
-						field.Field.Set(reflect.ValueOf(value).Elem())
-					} else if v := reflect.ValueOf(value).Elem().Elem(); v.IsValid() {
-						field.Field.Set(v)
+				if isSlice {
+					if isPtr {
+						dest.Set(reflect.Append(dest, elem.Addr()))
+					} else {
+						dest.Set(reflect.Append(dest, elem))
 					}
 				}
 			}
+			return scope.Err(rows.Err())
+		})
 
-			if isSlice {
-				if isPtr {
-					dest.Set(reflect.Append(dest, elem.Addr()))
-				} else {
-					dest.Set(reflect.Append(dest, elem))
-				}
-			}
-		}
-
-		if !anyRecordFound && !isSlice {
+		if !anyRecordFound && !isSlice && !scope.HasError() && !scope.dryRun() {
 			scope.Err(RecordNotFound)
 		}
 	}
@@ -14,6 +14,10 @@ func SaveBeforeAssociations(scope *Scope) {
 	if !scope.shouldSaveAssociations() {
 		return
 	}
+	scope.forEachElement(saveBeforeAssociations)
+}
+
+func saveBeforeAssociations(scope *Scope) {
 	for _, field := range scope.Fields() {
 		if scope.changeableField(field) && !field.IsBlank && !field.IsIgnored {
 			if relationship := field.Relationship; relationship != nil && relationship.Kind == "belongs_to" {
@@ -36,6 +40,10 @@ func SaveAfterAssociations(scope *Scope) {
 	if !scope.shouldSaveAssociations() {
 		return
 	}
+	scope.forEachElement(saveAfterAssociations)
+}
+
+func saveAfterAssociations(scope *Scope) {
 	for _, field := range scope.Fields() {
 		if scope.changeableField(field) && !field.IsBlank && !field.IsIgnored {
 			if relationship := field.Relationship; relationship != nil &&
@@ -10,6 +10,17 @@ func AssignUpdateAttributes(scope *Scope) {
 		if maps := convertInterfaceToMap(attrs); len(maps) > 0 {
 			protected, ok := scope.Get("gorm:ignore_protected_attrs")
 			_, updateColumn := scope.Get("gorm:update_column")
+			if versionField := scope.versionField(); versionField != nil && !updateColumn {
+				// the version column is maintained by Update, assigned values are ignored
+				delete(maps, versionField.DBName)
+			}
+
+			// fields not allowed to be updated are neither assigned nor updated
+			for key := range maps {
+				if field, ok := scope.FieldByName(key); ok && !field.IsUpdatable {
+					delete(maps, key)
+				}
+			}
 			updateAttrs, hasUpdate := scope.updatedAttrsWithValues(maps, ok && protected.(bool))
 
 			if updateColumn {
@@ -33,7 +44,9 @@ func BeforeUpdate(scope *Scope) {
 
 func UpdateTimeStampWhenUpdate(scope *Scope) {
 	if _, ok := scope.Get("gorm:update_column"); !ok {
-		scope.SetColumn("Updated_At", NowFunc())
+		now := NowFunc()
+		scope.SetColumn("Updated_At", now)
+		scope.setAutoTime("AUTOUPDATETIME", now)
 	}
 }
 
@@ -41,8 +54,17 @@ func Update(scope *Scope) {
 	if !scope.HasError() {
 		var sqls []string
 
+		// the version column is increased by updates, except UpdateColumn
+		versionField := scope.versionField()
+		if _, ok := scope.Get("gorm:update_column"); ok {
+			versionField = nil
+		}
+
 		if updateAttrs, ok := scope.InstanceGet("gorm:update_attrs"); ok {
 			for key, value := range updateAttrs.(map[string]interface{}) {
+				if versionField != nil && key == versionField.DBName {
+					continue
+				}
 				if scope.changeableDBColumn(key) {
 					sqls = append(sqls, fmt.Sprintf("%v = %v", scope.Quote(key), scope.AddToVars(value)))
 				}
@@ -50,7 +72,10 @@ func Update(scope *Scope) {
 		} else {
 			fields := scope.Fields()
 			for _, field := range fields {
-				if scope.changeableField(field) && !field.IsPrimaryKey && field.IsNormal {
+				if field == versionField {
+					continue
+				}
+				if scope.changeableField(field) && !field.IsPrimaryKey && field.IsNormal && field.IsUpdatable {
 					sqls = append(sqls, fmt.Sprintf("%v = %v", scope.Quote(field.DBName), scope.AddToVars(field.Field.Interface())))
 				} else if relationship := field.Relationship; relationship != nil && relationship.Kind == "belongs_to" {
 					for _, dbName := range relationship.ForeignDBNames {
@@ -63,6 +88,16 @@ func Update(scope *Scope) {
 			}
 		}
 
+		// records with primary key are only updated if their version isn't changed since they were found
+		var checkVersion bool
+		if versionField != nil && len(sqls) > 0 {
+			quotedVersion := scope.Quote(versionField.DBName)
+			sqls = append(sqls, fmt.Sprintf("%v = %v + 1", quotedVersion, quotedVersion))
+			if checkVersion = !scope.PrimaryKeyZero(); checkVersion {
+				scope.InstanceSet("gorm:version_condition", versionField)
+			}
+		}
+
 		if len(sqls) > 0 {
 			scope.Raw(fmt.Sprintf(
 				"UPDATE %v SET %v %v",
@@ -70,7 +105,17 @@ func Update(scope *Scope) {
 				strings.Join(sqls, ", "),
 				scope.CombinedConditionSql(),
 			))
-			scope.Exec()
+			scope.exec(OperationUpdate)
+
+			if checkVersion && !scope.HasError() && !scope.dryRun() {
+				// with chained conditions, nothing updated may be caused by them rather than the version, so it's not reported as stale
+				chained := len(scope.Search.whereConditions) > 0 || len(scope.Search.orConditions) > 0 || len(scope.Search.notConditions) > 0
+				if scope.db.RowsAffected > 0 {
+					scope.increaseVersion(versionField)
+				} else if !chained {
+					scope.Err(ErrStaleObject)
+				}
+			}
 		}
 	}
 }
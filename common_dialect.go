@@ -3,6 +3,7 @@ package gorm
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 )
 
@@ -16,11 +17,31 @@ func (commonDialect) SupportLastInsertId() bool {
 	return true
 }
 
+// FirstInsertId returns the id of the first row inserted by a multi-row insert,
+// most databases report it as the last insert id
+func (commonDialect) FirstInsertId(lastInsertId int64, rows int64) int64 {
+	return lastInsertId
+}
+
+// SupportConsecutiveInsertIds returns true if ids of rows inserted by a multi-row insert are guaranteed to be consecutive,
+// which isn't by mysql's interleaved auto increment lock mode, the default of mysql 8
+func (commonDialect) SupportConsecutiveInsertIds() bool {
+	return false
+}
+
 func (commonDialect) HasTop() bool {
 	return false
 }
 
+func (commonDialect) SupportRowValueComparison() bool {
+	return false
+}
+
 func (commonDialect) SqlTag(value reflect.Value, size int, autoIncrease bool) string {
+	if isJSON(value) {
+		return "JSON"
+	}
+
 	switch value.Kind() {
 	case reflect.Bool:
 		return "BOOLEAN"
@@ -60,10 +81,52 @@ func (commonDialect) ReturningStr(tableName, key string) string {
 	return ""
 }
 
+func (c commonDialect) UpsertSql(tableName string, primaryKey string, columns []string, values []string, onConflict OnConflict) (string, error) {
+	var quotedColumns, conflictColumns, updates []string
+	for _, column := range columns {
+		quotedColumns = append(quotedColumns, c.Quote(column))
+	}
+
+	for _, column := range onConflict.ConflictColumns() {
+		conflictColumns = append(conflictColumns, c.Quote(column))
+	}
+
+	for _, column := range onConflict.UpdateColumns(columns) {
+		updates = append(updates, fmt.Sprintf("%v = excluded.%v", c.Quote(column), c.Quote(column)))
+	}
+
+	var conflictTarget string
+	if len(conflictColumns) > 0 {
+		conflictTarget = fmt.Sprintf("(%v) ", strings.Join(conflictColumns, ","))
+	} else if !onConflict.DoNothing {
+		// DO UPDATE requires the conflict target
+		return "", ErrMissingConflict
+	}
+
+	action := "DO NOTHING"
+	if len(updates) > 0 {
+		action = "DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+
+	return fmt.Sprintf("INSERT INTO %v (%v) VALUES %v ON CONFLICT %v%v", tableName, strings.Join(quotedColumns, ","), strings.Join(values, ","), conflictTarget, action), nil
+}
+
 func (commonDialect) SelectFromDummyTable() string {
 	return ""
 }
 
+func (commonDialect) SavePointStr(name string) string {
+	return fmt.Sprintf("SAVEPOINT %v", name)
+}
+
+func (commonDialect) RollbackToSavePointStr(name string) string {
+	return fmt.Sprintf("ROLLBACK TO SAVEPOINT %v", name)
+}
+
+func (commonDialect) ReleaseSavePointStr(name string) string {
+	return fmt.Sprintf("RELEASE SAVEPOINT %v", name)
+}
+
 func (commonDialect) Quote(key string) string {
 	return fmt.Sprintf(`"%s"`, key)
 }
@@ -99,19 +162,37 @@ func (commonDialect) RemoveIndex(scope *Scope, indexName string) {
 	scope.Err(scope.NewDB().Exec(fmt.Sprintf("DROP INDEX %v ON %v", indexName, scope.QuotedTableName())).Error)
 }
 
+// inspectDB returns a DB to query the database's schema, which always runs on the primary database, even in dry run mode
+func inspectDB(scope *Scope) *DB {
+	return scope.NewDB().UsePrimary().Set("gorm:dry_run", false)
+}
+
 // RawScanInt scans the first column of the first row into the `scan' int pointer.
 // This function captures raw query errors and propagates them to the original scope.
 func (commonDialect) RawScanInt(scope *Scope, scanPtr *int, query string, args ...interface{}) {
-	scope.Err(scope.NewDB().Raw(query, args...).Row().Scan(scanPtr))
+	scope.Err(inspectDB(scope).Raw(query, args...).Row().Scan(scanPtr))
 }
 
 // RawScanString scans the first column of the first row into the `scan' string pointer.
 // This function captures raw query errors and propagates them to the original scope.
 func (commonDialect) RawScanString(scope *Scope, scanPtr *string, query string, args ...interface{}) {
-	scope.Err(scope.NewDB().Raw(query, args...).Row().Scan(scanPtr))
+	scope.Err(inspectDB(scope).Raw(query, args...).Row().Scan(scanPtr))
 }
 
 func (commonDialect) CurrentDatabase(scope *Scope) (name string) {
-	scope.Err(scope.NewDB().Raw("SELECT DATABASE()").Row().Scan(&name))
+	scope.Err(inspectDB(scope).Raw("SELECT DATABASE()").Row().Scan(&name))
 	return
 }
+
+func (commonDialect) TranslateError(err error) error {
+	return err
+}
+
+func (commonDialect) LiteralValue(value interface{}) string {
+	return formatLiteral(value, defaultLiteralFormat)
+}
+
+// JSONExtract returns the expression extracting the value at keys from the json column, the path is added to scope's vars
+func (commonDialect) JSONExtract(scope *Scope, column string, keys []string) string {
+	return fmt.Sprintf("JSON_EXTRACT(%v, %v)", column, scope.AddToVars(jsonPath(keys)))
+}
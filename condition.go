@@ -0,0 +1,138 @@
+package gorm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Condition is a typed condition built with Eq, Gt, In..., its Column is quoted with Scope.Quote, other binary operators could be used
+// by building it directly, e.g:
+//
+//	db.Where(&gorm.Condition{Column: "name", Operator: "ILIKE", Values: []interface{}{"jin%"}}).Find(&users)
+type Condition struct {
+	Column   string
+	Operator string
+	Values   []interface{}
+}
+
+// Eq builds a `column = value` condition, a nil value builds `column IS NULL`, e.g:
+//
+//	db.Where(gorm.Eq("name", "jinzhu")).Or(gorm.Gt("age", 20)).Find(&users)
+func Eq(column string, value interface{}) *Condition {
+	return &Condition{Column: column, Operator: "=", Values: []interface{}{value}}
+}
+
+// Neq builds a `column <> value` condition, a nil value builds `column IS NOT NULL`
+func Neq(column string, value interface{}) *Condition {
+	return &Condition{Column: column, Operator: "<>", Values: []interface{}{value}}
+}
+
+// Gt builds a `column > value` condition
+func Gt(column string, value interface{}) *Condition {
+	return &Condition{Column: column, Operator: ">", Values: []interface{}{value}}
+}
+
+// Gte builds a `column >= value` condition
+func Gte(column string, value interface{}) *Condition {
+	return &Condition{Column: column, Operator: ">=", Values: []interface{}{value}}
+}
+
+// Lt builds a `column < value` condition
+func Lt(column string, value interface{}) *Condition {
+	return &Condition{Column: column, Operator: "<", Values: []interface{}{value}}
+}
+
+// Lte builds a `column <= value` condition
+func Lte(column string, value interface{}) *Condition {
+	return &Condition{Column: column, Operator: "<=", Values: []interface{}{value}}
+}
+
+// In builds a `column IN (values...)` condition, values could be a slice or several values, no value matches no record
+func In(column string, values ...interface{}) *Condition {
+	if len(values) == 1 {
+		if reflectValue := reflect.ValueOf(values[0]); reflectValue.Kind() == reflect.Slice {
+			if _, ok := values[0].([]byte); !ok {
+				values = make([]interface{}, reflectValue.Len())
+				for i := range values {
+					values[i] = reflectValue.Index(i).Interface()
+				}
+			}
+		}
+	}
+	return &Condition{Column: column, Operator: "IN", Values: values}
+}
+
+// Like builds a `column LIKE pattern` condition
+func Like(column string, pattern interface{}) *Condition {
+	return &Condition{Column: column, Operator: "LIKE", Values: []interface{}{pattern}}
+}
+
+// Between builds a `column BETWEEN from AND to` condition
+func Between(column string, from, to interface{}) *Condition {
+	return &Condition{Column: column, Operator: "BETWEEN", Values: []interface{}{from, to}}
+}
+
+// IsNull builds a `column IS NULL` condition
+func IsNull(column string) *Condition {
+	return &Condition{Column: column, Operator: "IS NULL"}
+}
+
+func (c *Condition) sql(scope *Scope) string {
+	column := scope.Quote(c.Column)
+
+	var values []interface{}
+	for _, value := range c.Values {
+		if valuer, ok := value.(driver.Valuer); ok {
+			var err error
+			if value, err = valuer.Value(); scope.Err(err) != nil {
+				return ""
+			}
+		}
+		values = append(values, value)
+	}
+
+	switch c.Operator {
+	case "IS NULL":
+		return fmt.Sprintf("(%v IS NULL)", column)
+	case "IN":
+		if len(values) == 1 {
+			if db, ok := values[0].(*DB); ok {
+				return fmt.Sprintf("(%v IN (%v))", column, scope.subQuerySql(db))
+			}
+		}
+		if len(values) == 0 {
+			return "(1 <> 1)"
+		}
+		var marks []string
+		for _, value := range values {
+			marks = append(marks, scope.AddToVars(value))
+		}
+		return fmt.Sprintf("(%v IN (%v))", column, strings.Join(marks, ","))
+	case "BETWEEN":
+		if len(values) != 2 {
+			scope.Err(fmt.Errorf("BETWEEN condition of %v requires 2 values, but got %v", c.Column, len(values)))
+			return ""
+		}
+		return fmt.Sprintf("(%v BETWEEN %v AND %v)", column, scope.AddToVars(values[0]), scope.AddToVars(values[1]))
+	case "ANY":
+		if len(values) != 1 {
+			scope.Err(fmt.Errorf("ANY condition of %v requires 1 value, but got %v", c.Column, len(values)))
+			return ""
+		}
+		return fmt.Sprintf("(%v = ANY(%v))", scope.AddToVars(values[0]), column)
+	case "=", "<>":
+		if len(values) == 0 || values[0] == nil {
+			if c.Operator == "=" {
+				return fmt.Sprintf("(%v IS NULL)", column)
+			}
+			return fmt.Sprintf("(%v IS NOT NULL)", column)
+		}
+	}
+
+	if len(values) == 0 {
+		return fmt.Sprintf("(%v %v)", column, c.Operator)
+	}
+	return fmt.Sprintf("(%v %v %v)", column, c.Operator, scope.AddToVars(values[0]))
+}
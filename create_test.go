@@ -1,10 +1,17 @@
 package gorm_test
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	testdb "github.com/erikstmartin/go-testdb"
+	"github.com/jinzhu/gorm"
 )
 
 func TestCreate(t *testing.T) {
@@ -57,6 +64,222 @@ func TestCreate(t *testing.T) {
 	}
 }
 
+func TestCreateInBatches(t *testing.T) {
+	users := []User{
+		{Name: "batch_create_1", Age: 18, Emails: []Email{{Email: "batch_create_1@example.org"}}},
+		{Name: "batch_create_2", Age: 19},
+		{Name: "batch_create_3", Age: 20},
+	}
+
+	if err := DB.Set("gorm:batch_size", 2).Create(&users).Error; err != nil {
+		t.Errorf("No error should happen when create records in batches, but got %v", err)
+	}
+
+	for _, user := range users {
+		if user.Id == 0 {
+			t.Errorf("Primary key should be filled after create")
+		}
+
+		if user.Created_At.IsZero() {
+			t.Errorf("Should have created_at after create")
+		}
+
+		var newUser User
+		if err := DB.First(&newUser, user.Id).Error; err != nil || newUser.Name != user.Name || newUser.Age != user.Age {
+			t.Errorf("Should be able to find created record by primary key, expect %v, got %v", user.Name, newUser.Name)
+		}
+	}
+
+	if users[0].Emails[0].Id == 0 || DB.Model(&users[0]).Association("Emails").Count() != 1 {
+		t.Errorf("Associations should be saved for every record")
+	}
+
+	products := []*Product{{Code: "batch_create_1"}, {Code: "batch_create_2"}}
+	if result := DB.Create(&products); result.Error != nil || result.RowsAffected != 2 {
+		t.Errorf("Should create all records in one statement, but got %v, %v", result.RowsAffected, result.Error)
+	}
+
+	for _, product := range products {
+		if product.BeforeCreateCallTimes != 1 || product.BeforeSaveCallTimes != 1 || product.AfterSaveCallTimes != 1 {
+			t.Errorf("Callbacks should be invoked for every record, got %+v", product)
+		}
+	}
+
+	if err := DB.Create(&[]Product{{Code: "batch_create_3"}, {Code: "Invalid"}}).Error; err == nil {
+		t.Errorf("Should get error when a callback of one record failed")
+	}
+
+	if !DB.First(&Product{}, "code = ?", "batch_create_3").RecordNotFound() {
+		t.Errorf("Should rollback all records when a callback failed")
+	}
+}
+
+func TestCreateInBatchesWithoutConsecutiveIds(t *testing.T) {
+	db, err := gorm.Open("testdb", "")
+	if err != nil {
+		t.Fatalf("No error should happen when open test db, but got %v", err)
+	}
+	defer testdb.Reset()
+
+	var inserts int
+	testdb.SetExecWithArgsFunc(func(query string, args []driver.Value) (driver.Result, error) {
+		inserts++
+		return testdb.NewResult(int64(inserts*10), nil, 1, nil), nil
+	})
+
+	type BatchRecord struct {
+		Id   int64
+		Name string
+	}
+	records := []BatchRecord{{Name: "batch_1"}, {Name: "batch_2"}, {Name: "batch_3"}}
+	if err := db.Create(&records).Error; err != nil {
+		t.Errorf("No error should happen when create in batches, but got %v", err)
+	}
+
+	if inserts != 3 || records[0].Id != 10 || records[1].Id != 20 || records[2].Id != 30 {
+		t.Errorf("Records should be inserted one by one to get their ids without consecutive ids, but got %v inserts, %+v", inserts, records)
+	}
+}
+
+func TestCreateWithOnConflict(t *testing.T) {
+	type UpsertUser struct {
+		Id    int64
+		Email string `sql:"unique_index"`
+		Name  string
+		Age   int64
+	}
+	DB.DropTableIfExists(&UpsertUser{})
+	DB.AutoMigrate(&UpsertUser{})
+
+	user := UpsertUser{Email: "upsert@example.org", Name: "upsert", Age: 18}
+	DB.Create(&user)
+
+	upsertUser := UpsertUser{Email: "upsert@example.org", Name: "upsert_new", Age: 20}
+	onConflict := gorm.OnConflict{Columns: []string{"Email"}, DoUpdates: []string{"Name"}}
+	if err := DB.Set("gorm:on_conflict", onConflict).Create(&upsertUser).Error; err != nil {
+		t.Errorf("No error should happen when upsert, but got %v", err)
+	}
+
+	if upsertUser.Id != user.Id {
+		t.Errorf("Primary key should be filled with the conflicted record's, expect %v, got %v", user.Id, upsertUser.Id)
+	}
+
+	var newUser UpsertUser
+	DB.First(&newUser, user.Id)
+	if newUser.Name != "upsert_new" || newUser.Age != 18 {
+		t.Errorf("Only name should be updated when conflicted, but got %+v", newUser)
+	}
+
+	doNothingUser := UpsertUser{Email: "upsert@example.org", Name: "upsert_nothing"}
+	if err := DB.Set("gorm:on_conflict", gorm.OnConflict{Columns: []string{"email"}, DoNothing: true}).Create(&doNothingUser).Error; err != nil {
+		t.Errorf("No error should happen when upsert with do nothing, but got %v", err)
+	}
+
+	if DB.First(&newUser, user.Id); newUser.Name != "upsert_new" {
+		t.Errorf("Conflicted record should not be changed with do nothing, but got %+v", newUser)
+	}
+
+	if doNothingUser.Id != user.Id {
+		t.Errorf("Primary key should be filled with the conflicted record's with do nothing, expect %v, got %v", user.Id, doNothingUser.Id)
+	}
+
+	users := []UpsertUser{{Email: "upsert@example.org", Name: "upsert_batch", Age: 30}, {Email: "upsert_2@example.org", Name: "upsert_batch", Age: 30}}
+	if err := DB.Set("gorm:on_conflict", gorm.OnConflict{Columns: []string{"email"}}).Create(&users).Error; err != nil {
+		t.Errorf("No error should happen when upsert records in batch, but got %v", err)
+	}
+
+	var count int
+	if DB.Model(&UpsertUser{}).Where("name = ? AND age = ?", "upsert_batch", 30).Count(&count); count != 2 {
+		t.Errorf("All columns should be updated when no update columns specified, but got %v records updated", count)
+	}
+
+	if DB.First(&newUser, user.Id); newUser.Name != "upsert_batch" {
+		t.Errorf("Conflicted record should be updated in batch, but got %+v", newUser)
+	}
+
+	if dialect := os.Getenv("GORM_DIALECT"); dialect != "mysql" {
+		for _, onConflict := range []gorm.OnConflict{{}, {DoUpdates: []string{"name"}}} {
+			missingUser := UpsertUser{Email: "upsert@example.org", Name: "upsert_missing"}
+			if err := DB.Set("gorm:on_conflict", onConflict).Create(&missingUser).Error; err != gorm.ErrMissingConflict {
+				t.Errorf("Should get ErrMissingConflict when update conflicted records without conflict columns, but got %v", err)
+			}
+		}
+
+		if _, err := gorm.NewDialect("sqlite3").UpsertSql(`"upsert_users"`, "id", []string{"email"}, []string{"(?)"}, gorm.OnConflict{DoNothing: true}); err != nil {
+			t.Errorf("No error should happen when do nothing without conflict columns, but got %v", err)
+		}
+	}
+
+	if _, err := gorm.NewDialect("mssql").UpsertSql(`"upsert_users"`, "id", []string{"email"}, []string{"(?)"}, gorm.OnConflict{}); err != gorm.ErrMissingConflict {
+		t.Errorf("Should get error when upsert without conflict columns on mssql")
+	}
+
+	if sql, _ := gorm.NewDialect("mysql").UpsertSql("`upsert_users`", "id", []string{"email", "name"}, []string{"(?,?)"}, gorm.OnConflict{DoUpdates: []string{"name"}}); !strings.Contains(sql, "`id` = LAST_INSERT_ID(`id`)") {
+		t.Errorf("Should keep the conflicted record's primary key as last insert id on mysql, but got %v", sql)
+	}
+}
+
+func TestCreateWithDuplicatedKey(t *testing.T) {
+	type DuplicatedKeyUser struct {
+		Id    int64
+		Email string `sql:"unique_index"`
+	}
+	DB.DropTableIfExists(&DuplicatedKeyUser{})
+	DB.AutoMigrate(&DuplicatedKeyUser{})
+
+	DB.Create(&DuplicatedKeyUser{Email: "duplicated@example.org"})
+	err := DB.Create(&DuplicatedKeyUser{Email: "duplicated@example.org"}).Error
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Errorf("Should get ErrDuplicatedKey when create record with duplicated unique key, but got %v", err)
+	}
+
+	if err == gorm.ErrDuplicatedKey || strings.Contains(err.Error(), gorm.ErrDuplicatedKey.Error()) {
+		t.Errorf("Translated error should keep the database error, but got %v", err)
+	}
+
+	var errs gorm.Errors
+	errs.Add(errors.New("previous error"))
+	errs.Add(err)
+	if !errors.Is(errs, gorm.ErrDuplicatedKey) || errors.Is(errs, gorm.ErrDeadlock) {
+		t.Errorf("Errors should match the errors it contains with errors.Is")
+	}
+}
+
+func TestCreateWithLockedDatabase(t *testing.T) {
+	if dialect := os.Getenv("GORM_DIALECT"); dialect != "" && dialect != "sqlite" {
+		t.Skip("Skipping this because it locks a sqlite3 database file")
+	}
+
+	path := "/tmp/gorm_locked.db"
+	os.Remove(path)
+	defer os.Remove(path)
+
+	var dbs []gorm.DB
+	for i := 0; i < 2; i++ {
+		db, err := gorm.Open("sqlite3", path+"?_busy_timeout=0")
+		if err != nil {
+			t.Fatalf("No error should happen when open database, but got %v", err)
+		}
+		defer db.Close()
+		dbs = append(dbs, db)
+	}
+
+	type LockedUser struct {
+		Id   int64
+		Name string
+	}
+	dbs[0].AutoMigrate(&LockedUser{})
+
+	tx := dbs[0].Begin()
+	defer tx.Rollback()
+	tx.Create(&LockedUser{Name: "locking"})
+
+	err := dbs[1].Create(&LockedUser{Name: "locked"}).Error
+	if !errors.Is(err, gorm.ErrLocked) || errors.Is(err, gorm.ErrDeadlock) {
+		t.Errorf("Should get ErrLocked when the database is locked by another connection, but got %v", err)
+	}
+}
+
 func TestCreateWithNoGORMPrimayKey(t *testing.T) {
 	if dialect := os.Getenv("GORM_DIALECT"); dialect == "mssql" {
 		t.Skip("Skipping this because MSSQL will return identity only if the table has an Id column")
@@ -162,3 +385,61 @@ func TestOmitWithCreate(t *testing.T) {
 		t.Errorf("Should not create omited relationships")
 	}
 }
+
+type Account struct {
+	Id        int64
+	Name      string
+	CreatedBy string `gorm:"<-:create"`
+	Balance   int64  `gorm:"->"`
+	Locked    bool   `gorm:"<-:false"`
+	Password  string `gorm:"->:false;<-"`
+}
+
+func TestFieldPermissions(t *testing.T) {
+	DB.DropTable(&Account{})
+	DB.AutoMigrate(&Account{})
+
+	account := Account{Name: "account", CreatedBy: "admin", Balance: 100, Locked: true, Password: "secret"}
+	DB.Save(&account)
+
+	var createdBy, password string
+	var balance sql.NullInt64
+	var locked sql.NullBool
+	DB.Table("accounts").Where("id = ?", account.Id).Select("created_by, balance, locked, password").Row().Scan(&createdBy, &balance, &locked, &password)
+	if createdBy != "admin" || password != "secret" {
+		t.Errorf("Creatable fields should be inserted, but got %v, %v", createdBy, password)
+	}
+	if balance.Int64 != 0 || locked.Bool {
+		t.Errorf("Read only fields shouldn't be inserted, but got %v, %v", balance, locked)
+	}
+
+	DB.Exec("UPDATE accounts SET balance = ? WHERE id = ?", 200, account.Id)
+
+	var loaded Account
+	DB.First(&loaded, account.Id)
+	if loaded.Balance != 200 || loaded.CreatedBy != "admin" {
+		t.Errorf("Read only fields should be found, but got %#v", loaded)
+	}
+	if loaded.Password != "" {
+		t.Errorf("Fields without read permission shouldn't be selected, but got %v", loaded.Password)
+	}
+
+	loaded.Name, loaded.CreatedBy, loaded.Balance, loaded.Locked = "new name", "hacker", 0, true
+	DB.Save(&loaded)
+	DB.Model(&loaded).Updates(map[string]interface{}{"created_by": "hacker", "balance": 0, "password": "new secret"})
+	DB.Model(&loaded).UpdateColumn("locked", true)
+
+	DB.Table("accounts").Where("id = ?", account.Id).Select("created_by, balance, locked, password").Row().Scan(&createdBy, &balance, &locked, &password)
+	if createdBy != "admin" || balance.Int64 != 200 || locked.Bool {
+		t.Errorf("Fields without update permission shouldn't be updated, but got %v, %v, %v", createdBy, balance, locked)
+	}
+	if password != "new secret" {
+		t.Errorf("Fields with update permission should be updated, but got %v", password)
+	}
+
+	var scanned Account
+	DB.Raw("SELECT * FROM accounts WHERE id = ?", account.Id).Scan(&scanned)
+	if scanned.Name != "new name" || scanned.Password != "" {
+		t.Errorf("Fields without read permission shouldn't be scanned, but got %#v", scanned)
+	}
+}
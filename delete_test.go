@@ -3,6 +3,8 @@ package gorm_test
 import (
 	"testing"
 	"time"
+
+	"github.com/jinzhu/gorm"
 )
 
 func TestDelete(t *testing.T) {
@@ -66,3 +68,123 @@ func TestSoftDelete(t *testing.T) {
 		t.Errorf("Can't find permanently deleted record")
 	}
 }
+
+type Author struct {
+	Id        int64
+	Name      string
+	Books     []Book
+	DeletedAt gorm.DeletedAt
+}
+
+type Book struct {
+	Id       int64
+	AuthorId int64
+	Author   *Author
+	Title    string
+	Removed  int64 `gorm:"soft_delete:unix"`
+}
+
+type Draft struct {
+	Id        int64
+	Title     string
+	IsDeleted bool `gorm:"soft_delete"`
+}
+
+type LockedDraft struct {
+	Id        int64
+	Title     string
+	IsDeleted bool `gorm:"soft_delete;<-:create"`
+}
+
+func TestConfigurableSoftDelete(t *testing.T) {
+	DB.DropTable(&Author{}, &Book{}, &Draft{})
+	DB.AutoMigrate(&Author{}, &Book{}, &Draft{})
+
+	author := Author{Name: "author", Books: []Book{{Title: "book1"}, {Title: "book2"}}}
+	DB.Save(&author)
+
+	DB.Delete(&author.Books[0])
+	var removed int64
+	DB.Table("books").Where("id = ?", author.Books[0].Id).Select("removed").Row().Scan(&removed)
+	if removed == 0 {
+		t.Errorf("Unix seconds should be stored for soft deleted book, but got %v", removed)
+	}
+
+	if count := DB.Model(&author).Association("Books").Count(); count != 1 {
+		t.Errorf("Soft deleted book shouldn't be counted in association, but got %v", count)
+	}
+
+	var books []Book
+	DB.Model(&author).Related(&books)
+	if len(books) != 1 || books[0].Title != "book2" {
+		t.Errorf("Soft deleted book shouldn't be found with Related, but got %#v", books)
+	}
+
+	var preloaded Author
+	DB.Preload("Books").First(&preloaded, author.Id)
+	if len(preloaded.Books) != 1 || preloaded.Books[0].Title != "book2" {
+		t.Errorf("Soft deleted book shouldn't be preloaded, but got %#v", preloaded.Books)
+	}
+
+	DB.Unscoped().Preload("Books").First(&preloaded, author.Id)
+	if len(preloaded.Books) != 2 {
+		t.Errorf("Soft deleted book should be preloaded with Unscoped, but got %#v", preloaded.Books)
+	}
+
+	DB.Delete(&author)
+	if !DB.First(&Author{}, author.Id).RecordNotFound() {
+		t.Errorf("Soft deleted author shouldn't be found")
+	}
+
+	DB.Joins("Author").Find(&books, "books.id = ?", author.Books[1].Id)
+	if len(books) != 1 || books[0].Author != nil {
+		t.Errorf("Soft deleted author shouldn't be joined, but got %#v", books)
+	}
+
+	if err := DB.Unscoped().Restore(&author).Error; err != nil {
+		t.Errorf("No error should happen when restore author, but got %v", err)
+	}
+	if author.DeletedAt.Valid {
+		t.Errorf("Restored author's DeletedAt should be reset")
+	}
+	if err := DB.First(&Author{}, author.Id).Error; err != nil {
+		t.Errorf("Restored author should be found, but got %v", err)
+	}
+
+	draft := Draft{Title: "draft"}
+	DB.Save(&draft)
+	DB.Delete(&draft)
+	var isDeleted bool
+	DB.Table("drafts").Where("id = ?", draft.Id).Select("is_deleted").Row().Scan(&isDeleted)
+	if !isDeleted || !DB.First(&Draft{}, draft.Id).RecordNotFound() {
+		t.Errorf("Draft should be soft deleted with flag")
+	}
+
+	DB.Restore(Draft{}, "title = ?", "draft")
+	if err := DB.First(&Draft{}, draft.Id).Error; err != nil {
+		t.Errorf("Restored draft should be found, but got %v", err)
+	}
+
+	if err := DB.Restore(&Product{}).Error; err != gorm.ErrNotSoftDeletable {
+		t.Errorf("Should get ErrNotSoftDeletable when restore a model without soft delete field, but got %v", err)
+	}
+
+	if err := DB.Restore(&LockedDraft{Id: 1}).Error; err != gorm.ErrNotSoftDeletable {
+		t.Errorf("Should get ErrNotSoftDeletable when restore a model with read-only soft delete field, but got %v", err)
+	}
+
+	DB.Delete(&draft)
+	if err := DB.Restore(&Draft{}).Error; err != gorm.ErrMissingCondition {
+		t.Errorf("Should get ErrMissingCondition when restore without primary key and conditions, but got %v", err)
+	}
+	if !DB.First(&Draft{}, draft.Id).RecordNotFound() {
+		t.Errorf("Draft shouldn't be restored without primary key and conditions")
+	}
+
+	if err := DB.Where("title = ?", "draft").Restore(&Draft{}).Error; err != nil {
+		t.Errorf("No error should happen when restore with chained conditions, but got %v", err)
+	}
+	if err := DB.First(&Draft{}, draft.Id).Error; err != nil {
+		t.Errorf("Restored draft should be found, but got %v", err)
+	}
+}
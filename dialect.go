@@ -8,16 +8,26 @@ import (
 type Dialect interface {
 	BinVar(i int) string
 	SupportLastInsertId() bool
+	FirstInsertId(lastInsertId int64, rows int64) int64
+	SupportConsecutiveInsertIds() bool
 	HasTop() bool
+	SupportRowValueComparison() bool
 	SqlTag(value reflect.Value, size int, autoIncrease bool) string
 	ReturningStr(tableName, key string) string
+	UpsertSql(tableName string, primaryKey string, columns []string, values []string, onConflict OnConflict) (string, error)
 	SelectFromDummyTable() string
+	SavePointStr(name string) string
+	RollbackToSavePointStr(name string) string
+	ReleaseSavePointStr(name string) string
 	Quote(key string) string
 	HasTable(scope *Scope, tableName string) bool
 	HasColumn(scope *Scope, tableName string, columnName string) bool
 	HasIndex(scope *Scope, tableName string, indexName string) bool
 	RemoveIndex(scope *Scope, indexName string)
 	CurrentDatabase(scope *Scope) string
+	TranslateError(err error) error
+	LiteralValue(value interface{}) string
+	JSONExtract(scope *Scope, column string, keys []string) string
 }
 
 func NewDialect(driver string) Dialect {
@@ -0,0 +1,73 @@
+package gorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+)
+
+// dryRunDB answers the queries of Row and Rows in dry run mode, its rows return ErrDryRun without any records,
+// so callers could scan and close them as usual, transactions begun in dry run mode are begun on it without touching the database
+var dryRunDB = sql.OpenDB(dryRunConnector{})
+
+type dryRunConnector struct{}
+
+func (dryRunConnector) Connect(context.Context) (driver.Conn, error) {
+	return dryRunConn{}, nil
+}
+
+func (dryRunConnector) Driver() driver.Driver {
+	return dryRunDriver{}
+}
+
+type dryRunDriver struct{}
+
+func (dryRunDriver) Open(string) (driver.Conn, error) {
+	return dryRunConn{}, nil
+}
+
+type dryRunConn struct{}
+
+func (dryRunConn) Prepare(string) (driver.Stmt, error) {
+	return nil, ErrDryRun
+}
+
+func (dryRunConn) Close() error {
+	return nil
+}
+
+func (dryRunConn) Begin() (driver.Tx, error) {
+	return dryRunTx{}, nil
+}
+
+func (dryRunConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (dryRunConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return dryRunRows{}, nil
+}
+
+type dryRunTx struct{}
+
+func (dryRunTx) Commit() error {
+	return nil
+}
+
+func (dryRunTx) Rollback() error {
+	return nil
+}
+
+type dryRunRows struct{}
+
+func (dryRunRows) Columns() []string {
+	return nil
+}
+
+func (dryRunRows) Close() error {
+	return nil
+}
+
+func (dryRunRows) Next([]driver.Value) error {
+	return ErrDryRun
+}
@@ -11,8 +11,40 @@ var (
 	NoNewAttrs           = errors.New("no new attributes")
 	NoValidTransaction   = errors.New("no valid transaction")
 	CantStartTransaction = errors.New("can't start transaction")
+	ErrInvalidCursor     = errors.New("invalid cursor")
+	ErrStaleObject       = errors.New("stale object, it has been changed since it was found")
+	ErrNotSoftDeletable  = errors.New("model doesn't have a soft delete field")
+	ErrDryRun            = errors.New("statement is not executed in dry run mode")
+	ErrMissingCondition  = errors.New("primary key or where conditions required")
+	ErrMissingConflict   = errors.New("OnConflict.Columns required to match conflicted records")
+
+	// Errors translated from database errors by dialects, the original error is kept and could be retrieved with errors.As
+	ErrDuplicatedKey      = errors.New("duplicated key not allowed")
+	ErrForeignKeyViolated = errors.New("violates foreign key constraint")
+	ErrCheckConstraint    = errors.New("violates check constraint")
+	ErrDeadlock           = errors.New("deadlock detected")
+	ErrSerialization      = errors.New("could not serialize access")
+	ErrLocked             = errors.New("database is locked")
 )
 
+// translatedError is a database error classified as one of gorm's errors, it keeps the database error's message
+type translatedError struct {
+	kind error
+	err  error
+}
+
+func translateError(kind error, err error) error {
+	return &translatedError{kind: kind, err: err}
+}
+
+func (err *translatedError) Error() string {
+	return err.err.Error()
+}
+
+func (err *translatedError) Unwrap() []error {
+	return []error{err.kind, err.err}
+}
+
 type errorsInterface interface {
 	GetErrors() []error
 }
@@ -25,6 +57,11 @@ func (errs Errors) GetErrors() []error {
 	return errs.errors
 }
 
+// Unwrap returns all errors, so errors.Is and errors.As could match any of them
+func (errs Errors) Unwrap() []error {
+	return errs.errors
+}
+
 func (errs *Errors) Add(err error) {
 	if errors, ok := err.(errorsInterface); ok {
 		for _, err := range errors.GetErrors() {
@@ -0,0 +1,137 @@
+package gorm
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// literalFormat describes how a dialect writes values as sql literals
+type literalFormat struct {
+	timeLayout      string
+	trueValue       string
+	falseValue      string
+	escapeBackslash bool
+	bytes           func(b []byte) string
+}
+
+var defaultLiteralFormat = literalFormat{
+	timeLayout: "2006-01-02 15:04:05.999999999-07:00",
+	trueValue:  "TRUE",
+	falseValue: "FALSE",
+	bytes: func(b []byte) string {
+		return "X'" + hex.EncodeToString(b) + "'"
+	},
+}
+
+// formatLiteral writes value as a sql literal, pointers and driver.Valuer are resolved to their values, nil is written as NULL
+func formatLiteral(value interface{}, format literalFormat) string {
+	for value != nil {
+		reflectValue := reflect.ValueOf(value)
+		if reflectValue.Kind() == reflect.Ptr && reflectValue.IsNil() {
+			value = nil
+		} else if valuer, ok := value.(driver.Valuer); ok {
+			v, err := valuer.Value()
+			if err != nil {
+				return "NULL"
+			}
+			if _, ok := v.(driver.Valuer); ok {
+				value = fmt.Sprint(v)
+				break
+			}
+			value = v
+		} else if reflectValue.Kind() == reflect.Ptr {
+			value = reflectValue.Elem().Interface()
+		} else {
+			break
+		}
+	}
+
+	if value == nil {
+		return "NULL"
+	}
+
+	// values are formatted by their kinds, so named types like json.RawMessage are written with the dialect's format too
+	reflectValue := reflect.ValueOf(value)
+	switch reflectValue.Kind() {
+	case reflect.Bool:
+		if reflectValue.Bool() {
+			return format.trueValue
+		}
+		return format.falseValue
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(reflectValue.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(reflectValue.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(reflectValue.Float(), 'g', -1, 64)
+	case reflect.String:
+		return quoteLiteral(reflectValue.String(), format)
+	case reflect.Slice:
+		if reflectValue.Type().Elem().Kind() == reflect.Uint8 {
+			return format.bytes(reflectValue.Bytes())
+		}
+	case reflect.Struct:
+		if timeType := reflect.TypeOf(time.Time{}); reflectValue.Type().ConvertibleTo(timeType) {
+			return "'" + reflectValue.Convert(timeType).Interface().(time.Time).Format(format.timeLayout) + "'"
+		}
+	}
+	return quoteLiteral(fmt.Sprint(value), format)
+}
+
+func quoteLiteral(str string, format literalFormat) string {
+	if format.escapeBackslash {
+		str = strings.Replace(str, `\`, `\\`, -1)
+	}
+	return "'" + strings.Replace(str, "'", "''", -1) + "'"
+}
+
+// explainSql interpolates vars into sql with the dialect's literals, `$n` placeholders are always replaced,
+// `?` placeholders are replaced unless the dialect uses numbered placeholders, placeholders in quoted strings are kept
+func explainSql(dialect Dialect, sql string, vars []interface{}) string {
+	var (
+		result       strings.Builder
+		quote        byte
+		index        int
+		questionMark = dialect.BinVar(1) != "$1"
+	)
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			result.WriteByte(c)
+			continue
+		}
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			result.WriteByte(c)
+		case c == '?' && questionMark && index < len(vars):
+			result.WriteString(dialect.LiteralValue(vars[index]))
+			index++
+		case c == '$' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			j := i + 1
+			for j < len(sql) && sql[j] >= '0' && sql[j] <= '9' {
+				j++
+			}
+
+			if n, _ := strconv.Atoi(sql[i+1 : j]); n >= 1 && n <= len(vars) {
+				result.WriteString(dialect.LiteralValue(vars[n-1]))
+				i = j - 1
+			} else {
+				result.WriteByte(c)
+			}
+		default:
+			result.WriteByte(c)
+		}
+	}
+	return result.String()
+}
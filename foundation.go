@@ -18,7 +18,15 @@ func (foundation) SupportLastInsertId() bool {
 	return false
 }
 
+func (foundation) SupportRowValueComparison() bool {
+	return true
+}
+
 func (foundation) SqlTag(value reflect.Value, size int, autoIncrease bool) string {
+	if isJSON(value) {
+		return "clob"
+	}
+
 	switch value.Kind() {
 	case reflect.Bool:
 		return "boolean"
@@ -81,3 +89,11 @@ func (s foundation) CurrentDatabase(scope *Scope) (name string) {
 	s.RawScanString(scope, &name, "SELECT CURRENT_SCHEMA")
 	return
 }
+
+func (foundation) TranslateError(err error) error {
+	return postgres{}.TranslateError(err)
+}
+
+func (foundation) LiteralValue(value interface{}) string {
+	return formatLiteral(value, postgresLiteralFormat)
+}
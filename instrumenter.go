@@ -0,0 +1,36 @@
+package gorm
+
+import (
+	"context"
+	"time"
+)
+
+// Operation types of statement events
+const (
+	OperationCreate   = "create"
+	OperationQuery    = "query"
+	OperationUpdate   = "update"
+	OperationDelete   = "delete"
+	OperationRowQuery = "row_query"
+	OperationExec     = "exec"
+)
+
+// StatementEvent describes a statement executed by gorm, Duration, RowsAffected and Error are filled when it finished
+type StatementEvent struct {
+	Operation    string
+	Table        string
+	Sql          string
+	Vars         []interface{}
+	StartTime    time.Time
+	Duration     time.Duration
+	RowsAffected int64
+	Error        error
+}
+
+// Instrumenter is notified around every statement executed by gorm, it could be used to collect metrics or tracing spans
+type Instrumenter interface {
+	// StatementStart is called before executing the statement, the returned context is used to execute it and passed to StatementEnd
+	StatementStart(ctx context.Context, event *StatementEvent) context.Context
+	// StatementEnd is called after the statement finished
+	StatementEnd(ctx context.Context, event *StatementEvent)
+}
@@ -0,0 +1,142 @@
+package gorm
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// jsonValue is implemented by JSON and JSONType, which are stored in json columns of dialects
+type jsonValue interface {
+	isJSON()
+}
+
+func isJSON(value reflect.Value) bool {
+	_, ok := value.Interface().(jsonValue)
+	return ok
+}
+
+// JSON is a raw json value, stored as `jsonb` on postgres, `json` on mysql and text on others
+type JSON json.RawMessage
+
+func (JSON) isJSON() {}
+
+func (j JSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+func (j *JSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*j = append((*j)[0:0], v...)
+	case string:
+		*j = JSON(v)
+	case nil:
+		*j = nil
+	default:
+		return fmt.Errorf("failed to scan json from %T", value)
+	}
+	return nil
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	return json.RawMessage(j).MarshalJSON()
+}
+
+func (j *JSON) UnmarshalJSON(data []byte) error {
+	return (*json.RawMessage)(j).UnmarshalJSON(data)
+}
+
+// JSONType stores a value of T as json, e.g:
+//
+//	type User struct {
+//		ID         int
+//		Attributes gorm.JSONType[Attributes]
+//	}
+//
+//	db.Create(&User{Attributes: gorm.NewJSONType(Attributes{Role: "admin"})})
+type JSONType[T any] struct {
+	data T
+}
+
+// NewJSONType returns a JSONType storing data
+func NewJSONType[T any](data T) JSONType[T] {
+	return JSONType[T]{data: data}
+}
+
+// Data returns the stored value
+func (j JSONType[T]) Data() T {
+	return j.data
+}
+
+func (JSONType[T]) isJSON() {}
+
+func (j JSONType[T]) Value() (driver.Value, error) {
+	data, err := json.Marshal(j.data)
+	return string(data), err
+}
+
+func (j *JSONType[T]) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		var zero T
+		j.data = zero
+		return nil
+	default:
+		return fmt.Errorf("failed to scan json from %T", value)
+	}
+	return json.Unmarshal(data, &j.data)
+}
+
+func (j JSONType[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.data)
+}
+
+func (j *JSONType[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &j.data)
+}
+
+// JSONQueryExpression builds conditions on values in a json column, its column is quoted with Scope.Quote
+type JSONQueryExpression struct {
+	column string
+	keys   []string
+	value  interface{}
+}
+
+// JSONQuery starts a condition on the json column, e.g:
+//
+//	db.Where(gorm.JSONQuery("attributes").Equals("address.city", "Shanghai")).Find(&users)
+func JSONQuery(column string) *JSONQueryExpression {
+	return &JSONQueryExpression{column: column}
+}
+
+// Equals builds the condition that the value at path, keys joined with `.`, equals value
+func (jsonQuery *JSONQueryExpression) Equals(path string, value interface{}) *JSONQueryExpression {
+	return &JSONQueryExpression{column: jsonQuery.column, keys: strings.Split(path, "."), value: value}
+}
+
+func (jsonQuery *JSONQueryExpression) sql(scope *Scope) string {
+	if len(jsonQuery.keys) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("(%v = %v)", scope.Dialect().JSONExtract(scope, scope.Quote(jsonQuery.column), jsonQuery.keys), scope.AddToVars(jsonQuery.value))
+}
+
+// jsonPath returns the json path of keys used by mysql, sqlite3 and mssql, like `$."address"."city"`
+func jsonPath(keys []string) string {
+	path := "$"
+	for _, key := range keys {
+		path += fmt.Sprintf(`."%v"`, strings.Replace(key, `"`, `\"`, -1))
+	}
+	return path
+}
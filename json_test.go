@@ -0,0 +1,66 @@
+package gorm_test
+
+import (
+	"testing"
+
+	"github.com/jinzhu/gorm"
+)
+
+type Profile struct {
+	Role    string `json:"role"`
+	Address struct {
+		City string `json:"city"`
+	} `json:"address"`
+}
+
+type Member struct {
+	Id       int64
+	Name     string
+	Settings gorm.JSON
+	Profile  gorm.JSONType[Profile]
+}
+
+func TestJSON(t *testing.T) {
+	DB.DropTable(&Member{})
+	if err := DB.AutoMigrate(&Member{}).Error; err != nil {
+		t.Fatalf("No error should happen when migrate json columns, but got %v", err)
+	}
+
+	var profile Profile
+	profile.Role, profile.Address.City = "admin", "Shanghai"
+	member := Member{Name: "json", Settings: gorm.JSON(`{"theme":"dark"}`), Profile: gorm.NewJSONType(profile)}
+	DB.Save(&member)
+	DB.Save(&Member{Name: "json2", Profile: gorm.NewJSONType(Profile{Role: "user"})})
+
+	var result Member
+	if err := DB.First(&result, member.Id).Error; err != nil {
+		t.Errorf("No error should happen when find record with json columns, but got %v", err)
+	}
+	if string(result.Settings) != `{"theme":"dark"}` {
+		t.Errorf("Raw json should be saved, but got %s", result.Settings)
+	}
+	if data := result.Profile.Data(); data.Role != "admin" || data.Address.City != "Shanghai" {
+		t.Errorf("Json type should be saved, but got %#v", data)
+	}
+
+	// sqlite3 supports json functions when built with tag sqlite_json
+	if DB.Exec(`SELECT JSON_EXTRACT('{"a":1}', '$.a')`).Error != nil {
+		t.Skip("json functions are not supported by the database")
+	}
+
+	var members []Member
+	DB.Where(gorm.JSONQuery("profile").Equals("address.city", "Shanghai")).Find(&members)
+	if len(members) != 1 || members[0].Name != "json" {
+		t.Errorf("Should find member by json path, but got %#v", members)
+	}
+
+	DB.Not(gorm.JSONQuery("profile").Equals("role", "admin")).Find(&members)
+	if len(members) != 1 || members[0].Name != "json2" {
+		t.Errorf("Should find member with Not json query, but got %#v", members)
+	}
+
+	DB.Where(gorm.JSONQuery("settings").Equals("theme", "light")).Find(&members)
+	if len(members) != 0 {
+		t.Errorf("Shouldn't find member with unmatched json value, but got %#v", members)
+	}
+}
@@ -1,71 +1,133 @@
 package gorm
 
 import (
-	"database/sql/driver"
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
-	"reflect"
-	"regexp"
 	"time"
 )
 
-type logger interface {
-	Print(v ...interface{})
+// LogLevel is the severity of a log event, events more verbose than the DB's log level are discarded
+type LogLevel int
+
+const (
+	LogSilent LogLevel = iota + 1
+	LogError
+	LogWarn
+	LogInfo
+)
+
+func (level LogLevel) String() string {
+	switch level {
+	case LogSilent:
+		return "silent"
+	case LogError:
+		return "error"
+	case LogWarn:
+		return "warn"
+	case LogInfo:
+		return "info"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(level))
+}
+
+// LogEvent is a log entry passed to the logger, Sql, Vars, Duration and RowsAffected are only set for executed statements
+type LogEvent struct {
+	Level        LogLevel
+	Time         time.Time
+	Caller       string
+	Message      string
+	Sql          string
+	Vars         []interface{}
+	Duration     time.Duration
+	RowsAffected int64
+	Error        error
+}
+
+// LoggerInterface is the interface loggers need to implement to be used with DB.SetLogger
+type LoggerInterface interface {
+	Log(event LogEvent)
 }
 
 type LogWriter interface {
 	Println(v ...interface{})
 }
 
+// Logger is the default logger, it writes human readable colored messages to the LogWriter
 type Logger struct {
 	LogWriter
 }
 
 var defaultLogger = Logger{log.New(os.Stdout, "\r\n", 0)}
 
-// Format log
-var sqlRegexp = regexp.MustCompile(`(\$\d+)|\?`)
-
-func (logger Logger) Print(values ...interface{}) {
-	if len(values) > 1 {
-		level := values[0]
-		currentTime := "\n\033[33m[" + NowFunc().Format("2006-01-02 15:04:05") + "]\033[0m"
-		source := fmt.Sprintf("\033[35m(%v)\033[0m", values[1])
-		messages := []interface{}{source, currentTime}
-
-		if level == "sql" {
-			// duration
-			messages = append(messages, fmt.Sprintf(" \033[36;1m[%.2fms]\033[0m ", float64(values[2].(time.Duration).Nanoseconds()/1e4)/100.0))
-			// sql
-			var formatedValues []interface{}
-			for _, value := range values[4].([]interface{}) {
-				indirectValue := reflect.Indirect(reflect.ValueOf(value))
-				if indirectValue.IsValid() {
-					value = indirectValue.Interface()
-					if t, ok := value.(time.Time); ok {
-						formatedValues = append(formatedValues, fmt.Sprintf("'%v'", t.Format(time.RFC3339)))
-					} else if b, ok := value.([]byte); ok {
-						formatedValues = append(formatedValues, fmt.Sprintf("'%v'", string(b)))
-					} else if r, ok := value.(driver.Valuer); ok {
-						if value, err := r.Value(); err == nil && value != nil {
-							formatedValues = append(formatedValues, fmt.Sprintf("'%v'", value))
-						} else {
-							formatedValues = append(formatedValues, "NULL")
-						}
-					} else {
-						formatedValues = append(formatedValues, fmt.Sprintf("'%v'", value))
-					}
-				} else {
-					formatedValues = append(formatedValues, fmt.Sprintf("'%v'", value))
-				}
-			}
-			messages = append(messages, fmt.Sprintf(sqlRegexp.ReplaceAllString(values[3].(string), "%v"), formatedValues...))
-		} else {
-			messages = append(messages, "\033[31;1m")
-			messages = append(messages, values[2:]...)
-			messages = append(messages, "\033[0m")
+func (logger Logger) Log(event LogEvent) {
+	currentTime := "\n\033[33m[" + event.Time.Format("2006-01-02 15:04:05") + "]\033[0m"
+	source := fmt.Sprintf("\033[35m(%v)\033[0m", event.Caller)
+	messages := []interface{}{source, currentTime}
+
+	if event.Sql != "" {
+		// duration
+		messages = append(messages, fmt.Sprintf(" \033[36;1m[%.2fms]\033[0m ", float64(event.Duration.Nanoseconds()/1e4)/100.0))
+		// sql
+		messages = append(messages, explainSql(commonDialect{}, event.Sql, event.Vars))
+		messages = append(messages, fmt.Sprintf("\033[36;1m[%v rows affected]\033[0m", event.RowsAffected))
+
+		if event.Message != "" {
+			messages = append(messages, "\033[33;1m"+event.Message+"\033[0m")
 		}
-		logger.Println(messages...)
+		if event.Error != nil {
+			messages = append(messages, "\033[31;1m"+event.Error.Error()+"\033[0m")
+		}
+	} else if event.Error != nil {
+		messages = append(messages, "\033[31;1m", event.Error, "\033[0m")
+	} else {
+		messages = append(messages, event.Message)
 	}
+	logger.Println(messages...)
+}
+
+// SlogLogger writes log events as structured records with a log/slog logger, slog.Default() is used if Logger is nil
+type SlogLogger struct {
+	Logger *slog.Logger
+}
+
+func (logger SlogLogger) Log(event LogEvent) {
+	l := logger.Logger
+	if l == nil {
+		l = slog.Default()
+	}
+
+	level := slog.LevelInfo
+	switch event.Level {
+	case LogError:
+		level = slog.LevelError
+	case LogWarn:
+		level = slog.LevelWarn
+	}
+
+	attrs := []slog.Attr{slog.String("caller", event.Caller)}
+	if event.Sql != "" {
+		attrs = append(attrs,
+			slog.String("sql", event.Sql),
+			slog.Any("vars", event.Vars),
+			slog.Duration("duration", event.Duration),
+			slog.Int64("rows_affected", event.RowsAffected),
+		)
+	}
+	if event.Error != nil {
+		attrs = append(attrs, slog.String("error", event.Error.Error()))
+	}
+
+	message := event.Message
+	if message == "" {
+		if event.Sql != "" {
+			message = "sql"
+		} else if event.Error != nil {
+			message = event.Error.Error()
+		}
+	}
+
+	l.LogAttrs(context.Background(), level, message, attrs...)
 }
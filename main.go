@@ -1,11 +1,13 @@
 package gorm
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -27,8 +29,19 @@ type DB struct {
 	db                sqlCommon
 	parent            *DB
 	search            *search
-	logMode           int
-	logger            logger
+	ctx               context.Context
+	logLevel          LogLevel
+	slowThreshold     time.Duration
+	ignoreNotFound    bool
+	logger            LoggerInterface
+	instrumenter      Instrumenter
+	replicas          *replicaResolver
+	prepareStmt       bool
+	stmts             *stmtCache
+	savePoints        *int64
+	statementSql      string
+	statementVars     []interface{}
+	tracingStatement  bool
 	dialect           Dialect
 	singularTable     bool
 	source            string
@@ -39,16 +52,25 @@ type DB struct {
 func Open(dialect string, args ...interface{}) (DB, error) {
 	var db DB
 	var err error
+	var replicas []Replica
+
+	for i := 0; i < len(args); i++ {
+		if replica, ok := args[i].(Replica); ok {
+			replicas = append(replicas, replica)
+			args = append(args[:i:i], args[i+1:]...)
+			i--
+		}
+	}
 
 	if len(args) == 0 {
 		err = errors.New("invalid database source")
 	} else {
 		var source string
 		var dbSql sqlCommon
+		var driver = dialect
 
 		switch value := args[0].(type) {
 		case string:
-			var driver = dialect
 			if len(args) == 1 {
 				source = value
 			} else if len(args) >= 2 {
@@ -71,19 +93,41 @@ func Open(dialect string, args ...interface{}) (DB, error) {
 			source:   source,
 			values:   map[string]interface{}{},
 			db:       dbSql,
+			stmts:    newStmtCache(),
 		}
 		db.parent = &db
 
 		if err == nil {
 			err = db.DB().Ping() // Send a ping to make sure the database connection is alive.
 		}
+
+		if err == nil && len(replicas) > 0 {
+			if driver == "foundation" {
+				driver = "postgres"
+			}
+			if db.replicas, err = openReplicas(driver, replicas); err != nil {
+				if _, ok := args[0].(string); ok {
+					// the primary database opened from the data source name isn't returned to the caller to be closed
+					dbSql.(*sql.DB).Close()
+				}
+			}
+		}
 	}
 
 	return db, err
 }
 
+// Close closes the cached prepared statements, the replicas opened from data source names and the database, errors of them are joined
 func (s *DB) Close() error {
-	return s.parent.db.(*sql.DB).Close()
+	var errs []error
+	if s.parent.stmts != nil {
+		errs = append(errs, s.parent.stmts.close())
+	}
+
+	if s.parent.replicas != nil {
+		errs = append(errs, s.parent.replicas.close())
+	}
+	return errors.Join(append(errs, s.parent.db.(*sql.DB).Close())...)
 }
 
 func (s *DB) DB() *sql.DB {
@@ -111,24 +155,70 @@ func (s *DB) CommonDB() sqlCommon {
 	return s.db
 }
 
+// WithContext returns a new DB whose statements are executed with ctx, so they
+// could be cancelled or time out together with it
+func (s *DB) WithContext(ctx context.Context) *DB {
+	clone := s.clone()
+	clone.ctx = ctx
+	return clone
+}
+
+// Context returns the context used by the DB, context.Background() if none was set
+func (s *DB) Context() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return context.Background()
+}
+
 func (s *DB) Callback() *callback {
 	s.parent.callback = s.parent.callback.clone()
 	return s.parent.callback
 }
 
-func (s *DB) SetLogger(l logger) {
+func (s *DB) SetLogger(l LoggerInterface) {
 	s.logger = l
 }
 
+// SetInstrumenter set the instrumenter that will be notified around every executed statement
+func (s *DB) SetInstrumenter(instrumenter Instrumenter) {
+	s.instrumenter = instrumenter
+}
+
 func (s *DB) LogMode(enable bool) *DB {
 	if enable {
-		s.logMode = 2
+		s.logLevel = LogInfo
 	} else {
-		s.logMode = 1
+		s.logLevel = LogSilent
 	}
 	return s
 }
 
+// SetLogLevel set the most verbose level of events that will be logged, only errors are logged by default
+func (s *DB) SetLogLevel(level LogLevel) *DB {
+	s.logLevel = level
+	return s
+}
+
+// SetSlowThreshold log statements that take longer than threshold as warnings
+func (s *DB) SetSlowThreshold(threshold time.Duration) *DB {
+	s.slowThreshold = threshold
+	return s
+}
+
+// IgnoreRecordNotFoundError don't report RecordNotFound as the error of a statement in logs
+func (s *DB) IgnoreRecordNotFoundError(ignore bool) *DB {
+	s.ignoreNotFound = ignore
+	return s
+}
+
+// PrepareStmt execute statements with prepared statements cached by sql in the connection pool and transactions,
+// only queries and data changes are cached, up to 1000 statements for a DB, others are executed directly
+func (s *DB) PrepareStmt(enable bool) *DB {
+	s.prepareStmt = enable
+	return s
+}
+
 func (s *DB) SingularTable(enable bool) {
 	modelStructsMap = newModelStructsMap()
 	s.parent.singularTable = enable
@@ -174,8 +264,13 @@ func (s *DB) Having(query string, values ...interface{}) *DB {
 	return s.clone().search.Having(query, values...).db
 }
 
-func (s *DB) Joins(query string) *DB {
-	return s.clone().search.Joins(query).db
+// Joins specify join conditions, multiple calls are accumulated, pass the name of a belongs to or has one association
+// to left join it and scan its columns into the association, e.g:
+//
+//	db.Joins("JOIN emails ON emails.user_id = users.id AND emails.email = ?", "jinzhu@example.org").Find(&users)
+//	db.Joins("Company").Where(`"Company"."name" = ?`, "jinzhu").Find(&users)
+func (s *DB) Joins(query string, args ...interface{}) *DB {
+	return s.clone().search.Joins(query, args...).db
 }
 
 func (s *DB) Scopes(funcs ...func(*DB) *DB) *DB {
@@ -219,6 +314,103 @@ func (s *DB) Scan(dest interface{}) *DB {
 	return s.clone().NewScope(s.Value).Set("gorm:query_destination", dest).callCallbacks(s.parent.callback.queries).db
 }
 
+// ScanRows scan the current row of rows into dest, a pointer to a struct, map[string]interface{} or value, like Scan, e.g:
+//
+//	rows, err := db.Model(&User{}).Where("age > ?", 18).Rows()
+//	defer rows.Close()
+//	for rows.Next() {
+//		var user User
+//		db.ScanRows(rows, &user)
+//	}
+func (s *DB) ScanRows(rows *sql.Rows, dest interface{}) error {
+	scope := s.NewScope(dest)
+	if reflect.ValueOf(dest).Kind() != reflect.Ptr {
+		return errors.New("unsupported destination, should be pointer")
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	return scope.scanRow(rows, columns, scope.IndirectValue())
+}
+
+// FindInBatches find records into dest, a pointer to a slice, in batches of batchSize ordered by the primary key,
+// fc is called with the batch's number from 1 after every batch is found, no more batches are found if it returns an error, e.g:
+//
+//	db.Where("processed = ?", false).FindInBatches(&users, 500, func(tx *gorm.DB, batch int) error {
+//		return export(users)
+//	})
+func (s *DB) FindInBatches(dest interface{}, batchSize int, fc func(tx *DB, batch int) error) *DB {
+	db := s.clone()
+	scope := db.NewScope(dest)
+
+	primaryKey := scope.PrimaryKey()
+	if primaryKey == "" {
+		db.AddError(errors.New("primary key required to find in batches"))
+		return db
+	}
+
+	var rowsAffected int64
+	pagination := Pagination{Columns: []PageColumn{{Name: scope.TableName() + "." + primaryKey}}, Limit: batchSize}
+	for batch := 1; ; batch++ {
+		result := s.Paginate(dest, &pagination)
+		if result.Error != nil {
+			return result
+		}
+
+		count := reflect.Indirect(reflect.ValueOf(dest)).Len()
+		if count == 0 {
+			break
+		}
+		rowsAffected += int64(count)
+
+		tx := s.New()
+		tx.RowsAffected = int64(count)
+		if err := fc(tx, batch); err != nil {
+			db.AddError(err)
+			break
+		}
+
+		if pagination.NextCursor == "" {
+			break
+		}
+		pagination.Cursor = pagination.NextCursor
+	}
+
+	db.RowsAffected = rowsAffected
+	return db
+}
+
+// DryRun build statements without executing them, the statement could be got with Statement, e.g:
+//
+//	sql, vars := db.DryRun().Where("name = ?", "jinzhu").Find(&users).Statement()
+//
+// Row and Rows return rows without records in dry run mode, their Scan and Err return ErrDryRun,
+// Begin and Transaction don't begin transactions in the database, their statements are built like others
+func (s *DB) DryRun() *DB {
+	return s.Set("gorm:dry_run", true)
+}
+
+// Statement returns the sql and vars of the last statement built in dry run mode
+func (s *DB) Statement() (string, []interface{}) {
+	return s.statementSql, s.statementVars
+}
+
+// ToSQL returns the sql of the statement built by fc in dry run mode, with vars interpolated as the dialect's literals, e.g:
+//	db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+//		return tx.Where("name = ?", "jinzhu").Find(&users)
+//	})
+//	// SELECT * FROM "users" WHERE (name = 'jinzhu')
+func (s *DB) ToSQL(fc func(tx *DB) *DB) string {
+	return s.Explain(fc(s.DryRun()).Statement())
+}
+
+// Explain interpolates vars into sql as the dialect's literals, it's for debugging and logging, don't execute the result
+func (s *DB) Explain(sql string, vars []interface{}) string {
+	return explainSql(s.parent.dialect, sql, vars)
+}
+
 func (s *DB) Row() *sql.Row {
 	return s.NewScope(s.Value).row()
 }
@@ -305,6 +497,32 @@ func (s *DB) Delete(value interface{}, where ...interface{}) *DB {
 	return s.clone().NewScope(value).inlineCondition(where...).callCallbacks(s.parent.callback.deletes).db
 }
 
+// Restore undelete soft deleted records matching value's primary key and where conditions, e.g:
+//
+//	db.Unscoped().Restore(&user)
+//	db.Unscoped().Restore(User{}, "name = ?", "jinzhu")
+//
+// It returns ErrMissingCondition without primary key and conditions, and ErrNotSoftDeletable if the soft delete field isn't updatable
+func (s *DB) Restore(value interface{}, where ...interface{}) *DB {
+	db := s.Unscoped().Model(value)
+	scope := db.NewScope(value)
+	field := scope.softDeleteField()
+	if field == nil || !field.IsUpdatable {
+		db.AddError(ErrNotSoftDeletable)
+		return db
+	}
+
+	if len(where) > 0 {
+		db = db.Where(where[0], where[1:]...)
+	}
+
+	if scope.PrimaryKeyZero() && len(db.search.whereConditions) == 0 && len(db.search.orConditions) == 0 && len(db.search.notConditions) == 0 {
+		db.AddError(ErrMissingCondition)
+		return db
+	}
+	return db.UpdateColumn(field.DBName, reflect.Zero(field.Struct.Type).Interface())
+}
+
 func (s *DB) Raw(sql string, values ...interface{}) *DB {
 	return s.clone().search.Raw(true).Where(sql, values...).db
 }
@@ -323,9 +541,12 @@ func (s *DB) Model(value interface{}) *DB {
 	return c
 }
 
-func (s *DB) Table(name string) *DB {
+// Table specify the table to query, args are bound to its placeholders, e.g. to select from a subquery:
+//
+//	db.Table("(?) AS u", db.Model(&User{}).Select("name, age")).Where("age > ?", 18).Find(&users)
+func (s *DB) Table(name string, args ...interface{}) *DB {
 	clone := s.clone()
-	clone.search.Table(name)
+	clone.search.Table(name, args...)
 	clone.Value = nil
 	return clone
 }
@@ -334,11 +555,23 @@ func (s *DB) Debug() *DB {
 	return s.clone().LogMode(true)
 }
 
+// UsePrimary route queries to the primary database even if there are replicas, e.g. to read the data just written
+func (s *DB) UsePrimary() *DB {
+	return s.Set("gorm:use_primary", true)
+}
+
 func (s *DB) Begin() *DB {
 	c := s.clone()
-	if db, ok := c.db.(sqlDb); ok {
-		tx, err := db.Begin()
-		c.db = interface{}(tx).(sqlCommon)
+	if value, ok := c.Get("gorm:dry_run"); ok && value == true {
+		// transactions in dry run mode hold no connection, their statements are only built
+		tx, err := dryRunDB.BeginTx(c.Context(), nil)
+		c.db = tx
+		c.savePoints = new(int64)
+		c.AddError(err)
+	} else if db, ok := c.db.(sqlDb); ok {
+		tx, err := db.BeginTx(c.Context(), nil)
+		c.db = c.preparedTx(tx)
+		c.savePoints = new(int64)
 		c.AddError(err)
 	} else {
 		c.AddError(CantStartTransaction)
@@ -364,6 +597,124 @@ func (s *DB) Rollback() *DB {
 	return s
 }
 
+// SavePoint create a savepoint with name in current transaction
+func (s *DB) SavePoint(name string) *DB {
+	if _, ok := s.db.(sqlTx); ok {
+		_, err := s.db.ExecContext(s.Context(), s.parent.dialect.SavePointStr(name))
+		s.AddError(err)
+	} else {
+		s.AddError(NoValidTransaction)
+	}
+	return s
+}
+
+// ReleaseSavePoint release the savepoint with name in current transaction, it's ignored by dialects don't support releasing savepoints
+func (s *DB) ReleaseSavePoint(name string) *DB {
+	if _, ok := s.db.(sqlTx); ok {
+		if sql := s.parent.dialect.ReleaseSavePointStr(name); sql != "" {
+			_, err := s.db.ExecContext(s.Context(), sql)
+			s.AddError(err)
+		}
+	} else {
+		s.AddError(NoValidTransaction)
+	}
+	return s
+}
+
+// RollbackTo rollback current transaction to the savepoint with name
+func (s *DB) RollbackTo(name string) *DB {
+	if _, ok := s.db.(sqlTx); ok {
+		_, err := s.db.ExecContext(s.Context(), s.parent.dialect.RollbackToSavePointStr(name))
+		s.AddError(err)
+	} else {
+		s.AddError(NoValidTransaction)
+	}
+	return s
+}
+
+/*
+Transaction run fc in a transaction, commit it if fc returns nil, rollback it if fc returns an error or panics.
+If the DB is already in a transaction, a savepoint numbered in the transaction will be used, so only the changes made by fc will be rolled back,
+and the savepoint is released when fc returns nil
+
+Example:
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&email).Error
+	})
+*/
+func (s *DB) Transaction(fc func(tx *DB) error) (err error) {
+	panicked := true
+
+	if _, ok := s.db.(sqlTx); ok {
+		if s.savePoints == nil {
+			s.savePoints = new(int64)
+		}
+		savePoint := fmt.Sprintf("gorm_sp%d", atomic.AddInt64(s.savePoints, 1))
+		tx := s.clone()
+		if err = tx.SavePoint(savePoint).Error; err != nil {
+			return
+		}
+
+		defer func() {
+			if panicked || err != nil {
+				tx.RollbackTo(savePoint)
+			}
+		}()
+
+		if err = fc(tx); err == nil {
+			err = tx.ReleaseSavePoint(savePoint).Error
+		}
+	} else {
+		tx := s.Begin()
+		if err = tx.Error; err != nil {
+			return
+		}
+
+		defer func() {
+			if panicked || err != nil {
+				tx.Rollback()
+			}
+		}()
+
+		if err = fc(tx); err == nil {
+			err = tx.Commit().Error
+		}
+	}
+
+	panicked = false
+	return
+}
+
+/*
+WithRetry run fc in a transaction like Transaction, and rerun it in a new transaction when it failed with an error should be retried by the policy,
+until it succeeded or reached the policy's max attempts. If the DB is already in a transaction, fc is run only once, as the failure aborts the outer transaction
+
+Example:
+	err := db.WithRetry(gorm.RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Millisecond}, func(tx *gorm.DB) error {
+		return tx.Model(&account).UpdateColumn("balance", gorm.Expr("balance - ?", amount)).Error
+	})
+*/
+func (s *DB) WithRetry(policy RetryPolicy, fc func(tx *DB) error) (err error) {
+	if _, ok := s.db.(sqlTx); ok {
+		return s.Transaction(fc)
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err = s.Transaction(fc); attempt >= policy.MaxAttempts || !policy.ShouldRetry(err) {
+			return
+		}
+
+		select {
+		case <-s.Context().Done():
+			return fmt.Errorf("%w: %w", s.Context().Err(), err)
+		case <-time.After(policy.Delay(attempt + 1)):
+		}
+	}
+}
+
 func (s *DB) NewRecord(value interface{}) bool {
 	return s.clone().NewScope(value).PrimaryKeyZero()
 }
@@ -531,11 +882,17 @@ func (s *DB) SetJoinTableHandler(source interface{}, column string, handler Join
 
 func (s *DB) AddError(err error) error {
 	if err != nil {
+		if _, ok := err.(errorsInterface); !ok && s.parent != nil && s.parent.dialect != nil {
+			var translated *translatedError
+			if !errors.As(err, &translated) {
+				err = s.parent.dialect.TranslateError(err)
+			}
+		}
+
 		if err != RecordNotFound {
-			if s.logMode == 0 {
-				go s.print(fileWithLineNum(), err)
-			} else {
-				s.log(err)
+			// errors of running statements are logged with the statements by Scope.Trace
+			if !s.tracingStatement {
+				s.print(LogEvent{Level: LogError, Caller: fileWithLineNum(), Error: err})
 			}
 
 			errors := Errors{errors: s.GetErrors()}
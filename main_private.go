@@ -1,9 +1,13 @@
 package gorm
 
-import "time"
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
 
 func (s *DB) clone() *DB {
-	db := DB{db: s.db, parent: s.parent, logger: s.logger, logMode: s.logMode, values: map[string]interface{}{}, Value: s.Value, Error: s.Error}
+	db := DB{db: s.db, parent: s.parent, ctx: s.ctx, logger: s.logger, logLevel: s.logLevel, slowThreshold: s.slowThreshold, ignoreNotFound: s.ignoreNotFound, instrumenter: s.instrumenter, prepareStmt: s.prepareStmt, savePoints: s.savePoints, values: map[string]interface{}{}, Value: s.Value, Error: s.Error}
 
 	for key, value := range s.values {
 		db.values[key] = value
@@ -19,18 +23,56 @@ func (s *DB) clone() *DB {
 	return &db
 }
 
-func (s *DB) print(v ...interface{}) {
-	s.logger.(logger).Print(v...)
+func (s *DB) print(event LogEvent) {
+	level := s.logLevel
+	if level == 0 {
+		level = LogError
+	}
+
+	if event.Level <= level {
+		event.Time = NowFunc()
+		s.logger.Log(event)
+	}
 }
 
 func (s *DB) log(v ...interface{}) {
-	if s != nil && s.logMode == 2 {
-		s.print(append([]interface{}{"log", fileWithLineNum()}, v...)...)
+	if s != nil {
+		s.print(LogEvent{Level: LogInfo, Caller: fileWithLineNum(), Message: fmt.Sprint(v...)})
+	}
+}
+
+func (s *DB) slog(sql string, t time.Time, rowsAffected int64, err error, vars ...interface{}) {
+	event := LogEvent{Level: LogInfo, Caller: fileWithLineNum(), Sql: sql, Vars: vars, Duration: NowFunc().Sub(t), RowsAffected: rowsAffected, Error: err}
+	if err == RecordNotFound && s.ignoreNotFound {
+		event.Error = nil
+	}
+
+	if s.slowThreshold > 0 && event.Duration >= s.slowThreshold {
+		event.Level = LogWarn
+		event.Message = fmt.Sprintf("slow sql >= %v", s.slowThreshold)
+	}
+
+	// failed statements are logged as errors, RecordNotFound is a result of queries rather than a failure
+	if event.Error != nil && event.Error != RecordNotFound {
+		event.Level = LogError
+	}
+	s.print(event)
+}
+
+// preparedDB returns db which executes statements with cached prepared statements in PrepareStmt mode
+func (s *DB) preparedDB(db sqlCommon) sqlCommon {
+	if s.prepareStmt && s.parent.stmts != nil {
+		if _, ok := db.(sqlTx); !ok {
+			return &preparedStmtDB{sqlCommon: db, cache: s.parent.stmts}
+		}
 	}
+	return db
 }
 
-func (s *DB) slog(sql string, t time.Time, vars ...interface{}) {
-	if s.logMode == 2 {
-		s.print("sql", fileWithLineNum(), NowFunc().Sub(t), sql, vars)
+// preparedTx returns the transaction which executes statements with cached prepared statements in PrepareStmt mode
+func (s *DB) preparedTx(tx *sql.Tx) sqlCommon {
+	if s.prepareStmt && s.parent.stmts != nil && tx != nil {
+		return newPreparedStmtTx(tx, s.db, s.parent.stmts)
 	}
+	return interface{}(tx).(sqlCommon)
 }
@@ -1,10 +1,18 @@
 package gorm_test
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 
 	_ "github.com/denisenkom/go-mssqldb"
 	testdb "github.com/erikstmartin/go-testdb"
@@ -31,8 +39,8 @@ func init() {
 		panic(fmt.Sprintf("No error should happen when connecting to test database, but got err=%+v", err))
 	}
 
-	// DB.SetLogger(Logger{log.New(os.Stdout, "\r\n", 0)})
-	// DB.SetLogger(log.New(os.Stdout, "\r\n", 0))
+	// DB.SetLogger(gorm.Logger{log.New(os.Stdout, "\r\n", 0)})
+	// DB.SetLogger(gorm.SlogLogger{slog.New(slog.NewJSONHandler(os.Stdout, nil))})
 	if os.Getenv("DEBUG") == "true" {
 		DB.LogMode(true)
 	}
@@ -342,6 +350,246 @@ func TestTransaction(t *testing.T) {
 	}
 }
 
+func TestTransactionWithBlock(t *testing.T) {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&User{Name: "transaction-block"}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.First(&User{}, "name = ?", "transaction-block").Error; err != nil {
+			t.Errorf("Should find saved record in transaction")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("No error should raise, but got %v", err)
+	}
+
+	if err := DB.First(&User{}, "name = ?", "transaction-block").Error; err != nil {
+		t.Errorf("Should find committed record")
+	}
+
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		tx.Save(&User{Name: "transaction-block-rollback"})
+		return errors.New("rollback")
+	})
+
+	if err == nil || err.Error() != "rollback" {
+		t.Errorf("Should return the error of the block, but got %v", err)
+	}
+
+	if err := DB.First(&User{}, "name = ?", "transaction-block-rollback").Error; err == nil {
+		t.Errorf("Should not find record after rollback")
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Should keep panicking after rollback")
+			}
+		}()
+
+		DB.Transaction(func(tx *gorm.DB) error {
+			tx.Save(&User{Name: "transaction-block-panic"})
+			panic("rollback")
+		})
+	}()
+
+	if err := DB.First(&User{}, "name = ?", "transaction-block-panic").Error; err == nil {
+		t.Errorf("Should not find record after panic")
+	}
+}
+
+func TestNestedTransactionWithBlock(t *testing.T) {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		tx.Save(&User{Name: "nested-transaction"})
+
+		if err := tx.Transaction(func(tx1 *gorm.DB) error {
+			tx1.Save(&User{Name: "nested-transaction-rollback"})
+			return errors.New("rollback")
+		}); err == nil {
+			t.Errorf("Should return the error of the nested block")
+		}
+
+		if err := tx.First(&User{}, "name = ?", "nested-transaction-rollback").Error; err == nil {
+			t.Errorf("Should not find record rolled back to savepoint")
+		}
+
+		return tx.Transaction(func(tx1 *gorm.DB) error {
+			return tx1.Save(&User{Name: "nested-transaction-commit"}).Error
+		})
+	})
+
+	if err != nil {
+		t.Errorf("No error should raise, but got %v", err)
+	}
+
+	for _, name := range []string{"nested-transaction", "nested-transaction-commit"} {
+		if err := DB.First(&User{}, "name = ?", name).Error; err != nil {
+			t.Errorf("Should find committed record %v", name)
+		}
+	}
+
+	if err := DB.First(&User{}, "name = ?", "nested-transaction-rollback").Error; err == nil {
+		t.Errorf("Should not find record rolled back to savepoint")
+	}
+}
+
+func TestSiblingNestedTransactions(t *testing.T) {
+	nested := func(name string, fail bool) func(tx *gorm.DB) error {
+		return func(tx *gorm.DB) error {
+			if err := tx.Save(&Company{Name: name}).Error; err != nil {
+				return err
+			}
+			if fail {
+				return errors.New("rollback")
+			}
+			return nil
+		}
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, name := range []string{"sibling-transaction-1", "sibling-transaction-2"} {
+			tx.Transaction(nested(name, name == "sibling-transaction-2"))
+		}
+
+		if err := tx.First(&Company{}, "name = ?", "sibling-transaction-1").Error; err != nil {
+			t.Errorf("Should keep the record of the sibling transaction succeeded")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("No error should raise, but got %v", err)
+	}
+
+	if err := DB.First(&Company{}, "name = ?", "sibling-transaction-1").Error; err != nil {
+		t.Errorf("Should find the record committed by the first sibling transaction")
+	}
+
+	if err := DB.First(&Company{}, "name = ?", "sibling-transaction-2").Error; err == nil {
+		t.Errorf("Should not find the record rolled back by the second sibling transaction")
+	}
+
+	var recursive func(depth int) func(tx *gorm.DB) error
+	recursive = func(depth int) func(tx *gorm.DB) error {
+		return func(tx *gorm.DB) error {
+			tx.Save(&Company{Name: fmt.Sprintf("recursive-transaction-%v", depth)})
+			if depth == 1 {
+				tx.Transaction(recursive(depth + 1))
+				return errors.New("rollback")
+			}
+			return nil
+		}
+	}
+
+	DB.Transaction(func(tx *gorm.DB) error {
+		tx.Transaction(recursive(1))
+		for _, name := range []string{"recursive-transaction-1", "recursive-transaction-2"} {
+			if err := tx.First(&Company{}, "name = ?", name).Error; err == nil {
+				t.Errorf("Should rollback to the savepoint of the failed transaction, but found %v", name)
+			}
+		}
+		return nil
+	})
+}
+
+func TestWithRetry(t *testing.T) {
+	var attempts int
+	policy := gorm.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	err := DB.WithRetry(policy, func(tx *gorm.DB) error {
+		attempts++
+		tx.Save(&User{Name: fmt.Sprintf("with-retry-%v", attempts)})
+		if attempts < 3 {
+			return gorm.ErrSerialization
+		}
+		return nil
+	})
+
+	if err != nil || attempts != 3 {
+		t.Errorf("Should retry until succeeded, but got %v after %v attempts", err, attempts)
+	}
+
+	for i, name := range []string{"with-retry-1", "with-retry-2", "with-retry-3"} {
+		if found := !DB.First(&User{}, "name = ?", name).RecordNotFound(); found != (i == 2) {
+			t.Errorf("Only the succeeded attempt should be committed, but %v found: %v", name, found)
+		}
+	}
+
+	attempts = 0
+	err = DB.WithRetry(policy, func(tx *gorm.DB) error {
+		attempts++
+		return gorm.ErrDeadlock
+	})
+	if err != gorm.ErrDeadlock || attempts != 3 {
+		t.Errorf("Should stop retrying after max attempts, but got %v after %v attempts", err, attempts)
+	}
+
+	attempts = 0
+	err = DB.WithRetry(policy, func(tx *gorm.DB) error {
+		attempts++
+		return errors.New("not retryable")
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("Should not retry errors other than serialization failures and deadlocks, but got %v attempts", attempts)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts = 0
+	err = DB.WithContext(ctx).WithRetry(gorm.RetryPolicy{MaxAttempts: 3, Backoff: time.Hour}, func(tx *gorm.DB) error {
+		attempts++
+		cancel()
+		return gorm.ErrSerialization
+	})
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, gorm.ErrSerialization) || attempts != 1 {
+		t.Errorf("Should return the context error with the last error when cancelled, but got %v after %v attempts", err, attempts)
+	}
+
+	unlimited := gorm.RetryPolicy{Backoff: time.Second}
+	if delay := unlimited.Delay(100); delay <= 0 || delay > time.Minute {
+		t.Errorf("Delay should be capped without MaxBackoff, but got %v", delay)
+	}
+
+	limited := gorm.RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempt, expected := range map[int]time.Duration{2: time.Second, 3: 2 * time.Second, 4: 4 * time.Second, 5: 5 * time.Second, 1000: 5 * time.Second} {
+		if delay := limited.Delay(attempt); delay != expected {
+			t.Errorf("Delay of attempt %v should be %v, but got %v", attempt, expected, delay)
+		}
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	user := User{Name: "with_context"}
+	if err := DB.WithContext(context.Background()).Save(&user).Error; err != nil {
+		t.Errorf("No error should happen when save with context, but got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	db := DB.WithContext(ctx)
+	if db.Context() != ctx {
+		t.Errorf("Should return the context set with WithContext")
+	}
+
+	if err := db.First(&User{}, "name = ?", user.Name).Error; err != context.Canceled {
+		t.Errorf("Query should be cancelled with context, but got %v", err)
+	}
+
+	if err := db.Model(&user).Update("name", "with_context_2").Error; err != context.Canceled {
+		t.Errorf("Update should be cancelled with context, but got %v", err)
+	}
+
+	if _, err := db.Table("users").Rows(); err != context.Canceled {
+		t.Errorf("Rows should be cancelled with context, but got %v", err)
+	}
+
+	if DB.First(&User{}, "name = ?", "with_context").RecordNotFound() {
+		t.Errorf("Cancelled update should not change the record")
+	}
+}
+
 func TestRow(t *testing.T) {
 	user1 := User{Name: "RowUser1", Age: 1, Birthday: now.MustParse("2000-1-1")}
 	user2 := User{Name: "RowUser2", Age: 10, Birthday: now.MustParse("2010-1-1")}
@@ -441,6 +689,42 @@ func TestRaw(t *testing.T) {
 	}
 }
 
+func TestNamedArgs(t *testing.T) {
+	user1 := User{Name: "NamedArgUser1", Age: 1}
+	user2 := User{Name: "NamedArgUser2", Age: 10}
+	user3 := User{Name: "NamedArgUser3", Age: 20}
+	DB.Save(&user1).Save(&user2).Save(&user3)
+
+	var users []User
+	DB.Where("name = @name OR (age > @age AND name LIKE @prefix)", sql.Named("name", user1.Name), sql.Named("age", 5), sql.Named("prefix", "NamedArgUser%")).Order("age").Find(&users)
+	if len(users) != 3 {
+		t.Errorf("Should find 3 users with named args, but got %v", len(users))
+	}
+
+	DB.Where("name = @name AND name <> '@name ?'", sql.Named("name", user2.Name)).Find(&users)
+	if len(users) != 1 || users[0].Name != user2.Name {
+		t.Errorf("Should keep placeholders in quoted strings, but got %v", len(users))
+	}
+
+	DB.Where("name LIKE ? AND age >= @age", "NamedArgUser%", sql.Named("age", 10)).Find(&users)
+	if len(users) != 2 {
+		t.Errorf("Should mix positional and named args, but got %v", len(users))
+	}
+
+	var names []string
+	DB.Raw("SELECT name FROM users WHERE name IN (@names) AND age < @age ORDER BY age", map[string]interface{}{"names": []string{user1.Name, user3.Name}, "age": 30}).Pluck("name", &names)
+	if !reflect.DeepEqual(names, []string{user1.Name, user3.Name}) {
+		t.Errorf("Raw with map args should find user1 and user3, but got %v", names)
+	}
+
+	DB.Exec("UPDATE users SET age = @age WHERE name = @name OR name = @other", map[string]interface{}{"age": 50, "name": user1.Name, "other": user2.Name})
+	var count int
+	DB.Model(&User{}).Where("name LIKE ? AND age = ?", "NamedArgUser%", 50).Count(&count)
+	if count != 2 {
+		t.Errorf("Exec with map args should update 2 users, but got %v", count)
+	}
+}
+
 func TestGroup(t *testing.T) {
 	rows, err := DB.Select("name").Table("users").Group("name").Rows()
 
@@ -488,6 +772,62 @@ func TestJoinsWithSelect(t *testing.T) {
 	}
 }
 
+type JoinedUser struct {
+	Id        int64
+	Name      string
+	CompanyID *int
+	Company   *Company
+}
+
+func (JoinedUser) TableName() string {
+	return "users"
+}
+
+func TestJoinsWithAssociation(t *testing.T) {
+	company := Company{Name: "joins_company"}
+	DB.Save(&company)
+	companyID := int(company.Id)
+
+	user1 := User{Name: "joins_association1", CompanyID: &companyID, CreditCard: CreditCard{Number: "joins_card1"}}
+	user2 := User{Name: "joins_association2"}
+	DB.Save(&user1).Save(&user2)
+
+	var users []User
+	if err := DB.Joins("Company").Joins("CreditCard").Where("users.name LIKE ?", "joins_association%").Order("users.id").Find(&users).Error; err != nil {
+		t.Errorf("No error should happen when joins associations, but got %v", err)
+	}
+
+	if len(users) != 2 {
+		t.Fatalf("Should find 2 users with joined associations, but got %v", len(users))
+	}
+
+	if users[0].Company.Name != company.Name || users[0].Company.Id != company.Id || users[0].CreditCard.Number != "joins_card1" {
+		t.Errorf("Joined associations should be scanned, but got %#v, %#v", users[0].Company, users[0].CreditCard)
+	}
+
+	if users[1].Company.Name != "" || users[1].CreditCard.Number != "" || users[1].Name != user2.Name {
+		t.Errorf("Associations without joined record should be blank, but got %#v, %#v", users[1].Company, users[1].CreditCard)
+	}
+
+	var user User
+	DB.Joins("Company").Where(DB.NewScope(nil).Quote("Company")+".name = ?", company.Name).First(&user)
+	if user.Id != user1.Id || user.Company.Name != company.Name {
+		t.Errorf("Should find user by the joined association's columns")
+	}
+
+	var joinedUsers []JoinedUser
+	DB.Joins("Company").Where("users.name LIKE ?", "joins_association%").Order("users.id").Find(&joinedUsers)
+	if len(joinedUsers) != 2 || joinedUsers[0].Company == nil || joinedUsers[0].Company.Name != company.Name || joinedUsers[1].Company != nil {
+		t.Errorf("Pointer associations should be allocated only when joined record found")
+	}
+
+	var count int
+	DB.Model(&User{}).Joins("Company").Joins("LEFT JOIN emails ON emails.user_id = users.id AND emails.email = ?", "none").Where("users.name LIKE ?", "joins_association%").Count(&count)
+	if count != 2 {
+		t.Errorf("Joins should be accumulated with their own args, but got %v", count)
+	}
+}
+
 func TestHaving(t *testing.T) {
 	rows, err := DB.Select("name, count(*) as total").Table("users").Group("name").Having("name IN (?)", []string{"2", "3"}).Rows()
 
@@ -604,6 +944,84 @@ func TestHstore(t *testing.T) {
 	}
 }
 
+func TestPostgresArray(t *testing.T) {
+	type Article struct {
+		Id      int64
+		Tags    gorm.StringArray
+		Scores  gorm.Int64Array
+		Ratings gorm.Float64Array
+		Flags   gorm.BoolArray
+	}
+
+	postgres := gorm.NewDialect("postgres")
+	arrays := []struct {
+		value   interface{}
+		sqlType string
+	}{{gorm.StringArray{}, "text[]"}, {gorm.Int64Array{}, "bigint[]"}, {gorm.Float64Array{}, "numeric[]"}, {gorm.BoolArray{}, "boolean[]"}}
+	for _, array := range arrays {
+		if sqlType := postgres.SqlTag(reflect.ValueOf(array.value), 255, false); sqlType != array.sqlType {
+			t.Errorf("%T should be created as %v, but got %v", array.value, array.sqlType, sqlType)
+		}
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Plain slices can't be saved as postgres arrays, they should not be created as arrays")
+			}
+		}()
+		postgres.SqlTag(reflect.ValueOf([]int64{}), 255, false)
+	}()
+
+	sql, vars := DB.DryRun().Where(gorm.ArrayContains("tags", []string{"go", "orm"})).Where(gorm.ArrayAny("scores", 10)).
+		Where(gorm.ArrayOverlaps("tags", gorm.StringArray{"db"})).Find(&[]Article{}).Statement()
+	if !strings.Contains(sql, `("tags" @> `) || !strings.Contains(sql, `= ANY("scores"))`) || !strings.Contains(sql, `("tags" && `) || len(vars) != 3 {
+		t.Errorf("Array conditions should be built, but got %v %v", sql, vars)
+	} else if vars[0] != "{\"go\",\"orm\"}" || vars[1] != 10 || vars[2] != "{\"db\"}" {
+		t.Errorf("Arrays should be bound as postgres arrays, but got %#v", vars)
+	}
+
+	if _, vars := DB.DryRun().Where("name IN (?)", gorm.StringArray{"a", "b"}).Find(&[]User{}).Statement(); !reflect.DeepEqual(vars, []interface{}{"a", "b"}) {
+		t.Errorf("Arrays should be expanded in raw conditions, but got %#v", vars)
+	}
+
+	if dialect := os.Getenv("GORM_DIALECT"); dialect != "postgres" {
+		t.Skip()
+	}
+
+	DB.DropTable(&Article{})
+	if err := DB.AutoMigrate(&Article{}).Error; err != nil {
+		t.Fatalf("No error should happen when create table with arrays, but got %v", err)
+	}
+
+	article := Article{Tags: gorm.StringArray{"go", "orm"}, Scores: gorm.Int64Array{10, 20}, Ratings: gorm.Float64Array{4.5}, Flags: gorm.BoolArray{true, false}}
+	if err := DB.Save(&article).Error; err != nil {
+		t.Fatalf("No error should happen when save arrays, but got %v", err)
+	}
+	DB.Save(&Article{Tags: gorm.StringArray{"db"}, Scores: gorm.Int64Array{30}})
+
+	var loaded Article
+	if err := DB.First(&loaded, article.Id).Error; err != nil || !reflect.DeepEqual(loaded, article) {
+		t.Errorf("Arrays should be saved and loaded, expect %#v, but got %#v, %v", article, loaded, err)
+	}
+
+	var articles []Article
+	DB.Where(gorm.ArrayContains("tags", []string{"orm", "go"})).Find(&articles)
+	if len(articles) != 1 || articles[0].Id != article.Id {
+		t.Errorf("Should find article with ArrayContains, but got %#v", articles)
+	}
+
+	DB.Where(gorm.ArrayOverlaps("tags", gorm.StringArray{"db", "sql"})).Find(&articles)
+	if len(articles) != 1 || articles[0].Tags[0] != "db" {
+		t.Errorf("Should find article with ArrayOverlaps, but got %#v", articles)
+	}
+
+	DB.Where(gorm.ArrayAny("scores", 20)).Or(gorm.ArrayAny("scores", 30)).Find(&articles)
+	if len(articles) != 2 {
+		t.Errorf("Should find articles with ArrayAny, but got %#v", articles)
+	}
+}
+
 func TestSetAndGet(t *testing.T) {
 	if value, ok := DB.Set("hello", "world").Get("hello"); !ok {
 		t.Errorf("Should be able to get setting after set")
@@ -692,3 +1110,475 @@ func BenchmarkRawSql(b *testing.B) {
 		DB.Exec(deleteSql, id)
 	}
 }
+
+type recordLogger struct {
+	events []gorm.LogEvent
+}
+
+func (logger *recordLogger) Log(event gorm.LogEvent) {
+	logger.events = append(logger.events, event)
+}
+
+func TestLogger(t *testing.T) {
+	DB.Save(&User{Name: "logger", Age: 1})
+	logger := &recordLogger{}
+	db := DB.New()
+	db.SetLogger(logger)
+
+	db.Where("name = ?", "logger_not_found").First(&User{})
+	db.Model(&User{}).Where("name = ?", "logger").Update("age", 0)
+	if len(logger.events) != 0 {
+		t.Errorf("Statements should not be logged by default, but got %v", logger.events)
+	}
+
+	db.SetLogLevel(gorm.LogInfo).Where("name = ?", "logger_not_found").First(&User{})
+	if len(logger.events) != 1 {
+		t.Fatalf("Statement should be logged with info level, but got %v", logger.events)
+	}
+
+	event := logger.events[0]
+	if event.Level != gorm.LogInfo || !strings.Contains(event.Sql, "SELECT") || len(event.Vars) != 1 || event.Vars[0] != "logger_not_found" ||
+		event.Error != gorm.RecordNotFound || event.Caller == "" || event.Time.IsZero() {
+		t.Errorf("Log event should include the statement's details, but got %+v", event)
+	}
+
+	db.IgnoreRecordNotFoundError(true).Where("name = ?", "logger_not_found").First(&User{})
+	if event := logger.events[1]; event.Error != nil {
+		t.Errorf("RecordNotFound should be ignored, but got %v", event.Error)
+	}
+
+	db.SetLogLevel(gorm.LogWarn).SetSlowThreshold(time.Nanosecond).Find(&[]User{})
+	if event := logger.events[2]; event.Level != gorm.LogWarn || event.Message == "" || event.RowsAffected == 0 {
+		t.Errorf("Slow statement should be logged as warning, but got %+v", event)
+	}
+
+	db.SetLogLevel(gorm.LogError).Table("logger_missing_table").Find(&[]User{})
+	if event := logger.events[len(logger.events)-1]; event.Level != gorm.LogError || event.Error == nil {
+		t.Errorf("Error should be logged, but got %+v", event)
+	}
+
+	logger.events = nil
+	db.SetLogLevel(gorm.LogError).SetSlowThreshold(0).Table("logger_missing_table").Find(&[]User{})
+	var statementEvent *gorm.LogEvent
+	for idx, event := range logger.events {
+		if event.Sql != "" {
+			statementEvent = &logger.events[idx]
+		}
+	}
+	if statementEvent == nil || statementEvent.Level != gorm.LogError || statementEvent.Error == nil || !strings.Contains(statementEvent.Sql, "logger_missing_table") {
+		t.Errorf("Failed statement should be logged with error level, but got %+v", logger.events)
+	}
+	if len(logger.events) != 1 {
+		t.Errorf("Error of failed statement should be logged once, but got %+v", logger.events)
+	}
+
+	var buf bytes.Buffer
+	db.SetLogger(gorm.SlogLogger{Logger: slog.New(slog.NewJSONHandler(&buf, nil))})
+	db.SetLogLevel(gorm.LogInfo).SetSlowThreshold(0).IgnoreRecordNotFoundError(false).Where("name = ?", "logger_not_found").First(&User{})
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Log event should be written as json, but got %v", err)
+	}
+	if record["level"] != "INFO" || !strings.Contains(fmt.Sprint(record["sql"]), "SELECT") || record["error"] != gorm.RecordNotFound.Error() {
+		t.Errorf("Log event should be written with its attributes, but got %v", record)
+	}
+
+	buf.Reset()
+	db.SetLogLevel(gorm.LogError).Table("logger_missing_table").Find(&[]User{})
+	var failed bool
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var record map[string]interface{}
+		if json.Unmarshal(line, &record) == nil && record["sql"] != nil {
+			failed = record["level"] == "ERROR" && record["error"] != nil
+		}
+	}
+	if !failed {
+		t.Errorf("Failed statement should be written with error level and attribute, but got %v", buf.String())
+	}
+}
+
+type instrumenterContextKey struct{}
+
+type recordInstrumenter struct {
+	started []gorm.StatementEvent
+	ended   []gorm.StatementEvent
+}
+
+func (instrumenter *recordInstrumenter) StatementStart(ctx context.Context, event *gorm.StatementEvent) context.Context {
+	instrumenter.started = append(instrumenter.started, *event)
+	return context.WithValue(ctx, instrumenterContextKey{}, len(instrumenter.started))
+}
+
+func (instrumenter *recordInstrumenter) StatementEnd(ctx context.Context, event *gorm.StatementEvent) {
+	if ctx.Value(instrumenterContextKey{}) == len(instrumenter.started) {
+		instrumenter.ended = append(instrumenter.ended, *event)
+	}
+}
+
+func TestInstrumenter(t *testing.T) {
+	instrumenter := &recordInstrumenter{}
+	db := DB.New()
+	db.SetInstrumenter(instrumenter)
+
+	user := User{Name: "instrumenter", Age: 10}
+	db.Create(&user)
+	db.First(&User{}, user.Id)
+	db.Model(&user).Update("age", 20)
+	db.Table("users").Where("id = ?", user.Id).Select("name").Row()
+	db.Exec("UPDATE users SET age = ? WHERE id = ?", 30, user.Id)
+	db.Delete(&user)
+	db.Table("instrumenter_missing_table").Find(&[]User{})
+
+	var operations []string
+	for _, event := range instrumenter.ended {
+		if event.Table == "users" || event.Operation == gorm.OperationExec {
+			operations = append(operations, event.Operation)
+		}
+	}
+
+	expected := []string{gorm.OperationCreate, gorm.OperationQuery, gorm.OperationUpdate, gorm.OperationRowQuery, gorm.OperationExec, gorm.OperationDelete}
+	if !reflect.DeepEqual(operations, expected) {
+		t.Errorf("Statements should be instrumented with their operations, expect %v, got %v", expected, operations)
+	}
+
+	if len(instrumenter.started) != len(instrumenter.ended) {
+		t.Errorf("StatementEnd should be called with the context returned by StatementStart")
+	}
+
+	for _, event := range instrumenter.ended {
+		if event.Sql == "" || event.StartTime.IsZero() {
+			t.Errorf("Statement event should include sql and start time, but got %+v", event)
+		}
+	}
+
+	if event := instrumenter.ended[len(instrumenter.ended)-1]; event.Table != "instrumenter_missing_table" || event.Error == nil {
+		t.Errorf("Statement event should include error, but got %+v", event)
+	}
+
+	db.Table("instrumenter_missing_table").Select("name").Row()
+	if event := instrumenter.ended[len(instrumenter.ended)-1]; event.Operation != gorm.OperationRowQuery || event.Error == nil {
+		t.Errorf("Row query event should include error, but got %+v", event)
+	}
+}
+
+func TestReplicas(t *testing.T) {
+	if dialect := os.Getenv("GORM_DIALECT"); dialect != "" && dialect != "sqlite" {
+		t.Skip("Skipping this because replicas are simulated with sqlite3 databases")
+	}
+
+	var replicas []*sql.DB
+	for i := 0; i < 2; i++ {
+		path := fmt.Sprintf("/tmp/gorm_replica_%v.db", i)
+		os.Remove(path)
+		replica, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatalf("No error should happen when open replica, but got %v", err)
+		}
+		defer os.Remove(path)
+		defer replica.Close()
+
+		replica.Exec("CREATE TABLE users (id integer primary key, name varchar(255))")
+		if i == 0 {
+			replica.Exec("INSERT INTO users (name) VALUES (?)", "replica_user")
+		}
+		replicas = append(replicas, replica)
+	}
+
+	db, err := gorm.Open("sqlite3", DB.DB(), gorm.Replica{Source: replicas[0], Weight: 2}, gorm.Replica{Source: replicas[1]})
+	if err != nil {
+		t.Fatalf("No error should happen when open with replicas, but got %v", err)
+	}
+
+	var found int
+	for i := 0; i < 3; i++ {
+		if !db.First(&User{}, "name = ?", "replica_user").RecordNotFound() {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Errorf("Queries should be routed to replicas by weights, but found from the first replica %v times", found)
+	}
+
+	var count int
+	if db.Table("users").Where("name = ?", "replica_user").Count(&count); count != 1 {
+		t.Errorf("Row queries should be routed to replicas")
+	}
+
+	user := User{Name: "replica_primary_user"}
+	if err := db.Save(&user).Error; err != nil || user.Id == 0 {
+		t.Errorf("Should create record in the primary database, but got %v", err)
+	}
+
+	if err := db.UsePrimary().First(&User{}, "name = ?", "replica_primary_user").Error; err != nil {
+		t.Errorf("Should find record from the primary database with UsePrimary, but got %v", err)
+	}
+
+	if !db.UsePrimary().First(&User{}, "name = ?", "replica_user").RecordNotFound() {
+		t.Errorf("Should not query replicas with UsePrimary")
+	}
+
+	db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&User{}, "name = ?", "replica_primary_user").Error; err != nil {
+			t.Errorf("Should query the primary database in transaction, but got %v", err)
+		}
+		return nil
+	})
+
+	if db.Set("gorm:use_primary", false).First(&User{}, "name = ?", "replica_primary_user").Error == nil {
+		t.Errorf("Should query replicas when gorm:use_primary is false")
+	}
+
+	primaryPath := "/tmp/gorm_replica_primary.db"
+	defer os.Remove(primaryPath)
+	replicaPath := "/tmp/gorm_replica_opened.db"
+	defer os.Remove(replicaPath)
+
+	ownedDB, err := gorm.Open("sqlite3", primaryPath, gorm.Replica{Source: replicas[0]}, gorm.Replica{Source: replicaPath})
+	if err != nil {
+		t.Fatalf("No error should happen when open with replicas, but got %v", err)
+	}
+
+	if err := ownedDB.Close(); err != nil {
+		t.Errorf("No error should happen when close, but got %v", err)
+	}
+
+	if err := replicas[0].Ping(); err != nil {
+		t.Errorf("Replicas passed as *sql.DB should not be closed with the DB, but got %v", err)
+	}
+
+	failedDB, err := gorm.Open("sqlite3", primaryPath, gorm.Replica{Source: 1})
+	if err == nil {
+		t.Errorf("Should get error when open with invalid replica")
+	} else if err := failedDB.DB().Ping(); err == nil {
+		t.Errorf("Primary database should be closed when failed to open replicas")
+	}
+}
+
+func TestPrepareStmt(t *testing.T) {
+	db := DB.New().PrepareStmt(true)
+
+	for i := 0; i < 2; i++ {
+		user := User{Name: fmt.Sprintf("prepare_stmt_%v", i), Age: 20}
+		if err := db.Create(&user).Error; err != nil || user.Id == 0 {
+			t.Errorf("No error should happen when create with prepared statements, but got %v", err)
+		}
+
+		var result User
+		if err := db.First(&result, "name = ?", user.Name).Error; err != nil || result.Id != user.Id {
+			t.Errorf("Should find record with prepared statements, but got %v", err)
+		}
+
+		if err := db.Model(&user).Update("age", 30).Error; err != nil {
+			t.Errorf("No error should happen when update with prepared statements, but got %v", err)
+		}
+	}
+
+	var count int
+	if db.Model(&User{}).Where("name LIKE ? AND age = ?", "prepare_stmt_%", 30).Count(&count); count != 2 {
+		t.Errorf("Should count records with prepared statements, but got %v", count)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for i := 0; i < 2; i++ {
+			if err := tx.Create(&User{Name: "prepare_stmt_transaction"}).Error; err != nil {
+				return err
+			}
+		}
+		return errors.New("rollback")
+	})
+
+	if err == nil || !db.First(&User{}, "name = ?", "prepare_stmt_transaction").RecordNotFound() {
+		t.Errorf("Statements in transaction should be rolled back")
+	}
+
+	if err := db.Where("unknown_column = ?", 1).First(&User{}).Error; err == nil {
+		t.Errorf("Should get error when prepare invalid sql")
+	}
+
+	type PreparedStmtTable struct {
+		Id   int64
+		Name string
+	}
+	for i := 0; i < 2; i++ {
+		if err := db.DropTableIfExists(&PreparedStmtTable{}).AutoMigrate(&PreparedStmtTable{}).Error; err != nil {
+			t.Errorf("Schema changes should work with prepared statements, but got %v", err)
+		}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, name := range []string{"nested_1", "nested_2"} {
+			tx.Transaction(func(tx1 *gorm.DB) error {
+				tx1.Create(&PreparedStmtTable{Name: name})
+				if name == "nested_2" {
+					return errors.New("rollback")
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+
+	if err != nil || db.First(&PreparedStmtTable{}, "name = ?", "nested_1").Error != nil || !db.First(&PreparedStmtTable{}, "name = ?", "nested_2").RecordNotFound() {
+		t.Errorf("Nested transactions should use savepoints with prepared statements, but got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := db.First(&PreparedStmtTable{}, "name = ?", "nested_1").Error; err != nil {
+				t.Errorf("Should find record with prepared statements concurrently, but got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	single, err := OpenTestConnection()
+	if err != nil {
+		t.Fatalf("No error should happen when connecting to test database, but got %v", err)
+	}
+	defer single.Close()
+	single.DB().SetMaxOpenConns(1)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- single.PrepareStmt(true).Create(&PreparedStmtTable{Name: "single_connection"}).Error
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("No error should happen when create with prepared statements and a single connection, but got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Create with prepared statements and a single connection shouldn't block")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	user := User{Name: "dry_run", Age: 20}
+	result := DB.DryRun().Create(&user)
+	if sql, vars := result.Statement(); result.Error != nil || !strings.HasPrefix(sql, "INSERT INTO") || len(vars) == 0 {
+		t.Errorf("Should build the insert statement, but got %v, %v, %v", sql, vars, result.Error)
+	}
+
+	if !DB.First(&User{}, "name = ?", "dry_run").RecordNotFound() {
+		t.Errorf("Should not create record in dry run mode")
+	}
+
+	DB.Save(&user)
+	if sql, vars := DB.DryRun().Model(&user).Update("age", 30).Statement(); !strings.HasPrefix(sql, "UPDATE") || len(vars) == 0 {
+		t.Errorf("Should build the update statement, but got %v, %v", sql, vars)
+	}
+
+	result = DB.DryRun().Where("name = ?", "dry_run").First(&User{})
+	if sql, vars := result.Statement(); result.Error != nil || !strings.HasPrefix(sql, "SELECT") || !reflect.DeepEqual(vars, []interface{}{"dry_run"}) {
+		t.Errorf("Should build the query statement without RecordNotFound error, but got %v, %v, %v", sql, vars, result.Error)
+	}
+
+	var count int
+	if sql, _ := DB.DryRun().Model(&User{}).Where("name = ?", "dry_run").Count(&count).Statement(); !strings.Contains(sql, "count(*)") {
+		t.Errorf("Should build the count statement, but got %v", sql)
+	}
+
+	if sql, _ := DB.DryRun().Delete(&user).Statement(); sql == "" {
+		t.Errorf("Should build the delete statement")
+	}
+
+	var name string
+	if err := DB.DryRun().Table("users").Select("name").Where("id = ?", user.Id).Row().Scan(&name); err != gorm.ErrDryRun {
+		t.Errorf("Row should scan ErrDryRun in dry run mode, but got %v", err)
+	}
+
+	rows, err := DB.DryRun().Table("users").Select("name").Rows()
+	if err != nil {
+		t.Errorf("Rows should not return error in dry run mode, but got %v", err)
+	} else {
+		if rows.Next() || rows.Err() != gorm.ErrDryRun {
+			t.Errorf("Rows should return no records and ErrDryRun in dry run mode, but got %v", rows.Err())
+		}
+		if err := rows.Close(); err != nil {
+			t.Errorf("Rows should be closed in dry run mode, but got %v", err)
+		}
+	}
+
+	var names []string
+	if sql, _ := DB.DryRun().Model(&User{}).Pluck("name", &names).Statement(); !strings.HasPrefix(sql, "SELECT") || len(names) != 0 {
+		t.Errorf("Should build the pluck statement, but got %v, %v", sql, names)
+	}
+
+	err = DB.DryRun().Transaction(func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx1 *gorm.DB) error {
+			return tx1.Model(&user).Update("age", 40).Error
+		})
+	})
+	if err != nil {
+		t.Errorf("No error should happen when run transactions in dry run mode, but got %v", err)
+	}
+
+	single, err := OpenTestConnection()
+	if err != nil {
+		t.Fatalf("No error should happen when connecting to test database, but got %v", err)
+	}
+	defer single.Close()
+	single.DB().SetMaxOpenConns(1)
+
+	tx := single.DryRun().Begin()
+	done := make(chan error, 1)
+	go func() {
+		done <- single.First(&User{}, user.Id).Error
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("No error should happen when query out of a dry run transaction, but got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Transactions in dry run mode shouldn't hold a database connection")
+	}
+	if err := tx.Commit().Error; err != nil {
+		t.Errorf("No error should happen when commit a dry run transaction, but got %v", err)
+	}
+
+	var newUser User
+	if DB.First(&newUser, user.Id); newUser.Age != 20 {
+		t.Errorf("Should not update or delete record in dry run mode, but got %+v", newUser)
+	}
+}
+
+func TestToSQL(t *testing.T) {
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("name = ? AND age = ?", "it's ?", 18).Where("birthday IS ? OR birthday > ?", (*time.Time)(nil), now.MustParse("2015-01-02")).Find(&[]User{})
+	})
+
+	for _, expected := range []string{"name = 'it''s ?'", "age = 18", "birthday IS NULL", "birthday > '2015-01-02"} {
+		if !strings.Contains(sql, expected) {
+			t.Errorf("Interpolated sql should contain %v, but got %v", expected, sql)
+		}
+	}
+
+	if DB.First(&User{}, "name = ?", "it's ?").Error != gorm.RecordNotFound {
+		t.Errorf("ToSQL should not execute the statement")
+	}
+
+	sql, vars := DB.DryRun().Model(&User{}).Where("id = ?", 1).UpdateColumn("name", "to_sql").Statement()
+	if explained := DB.Explain(sql, vars); !strings.Contains(explained, "'to_sql'") || strings.Contains(explained, "= ?") || strings.Contains(explained, "$1") {
+		t.Errorf("All vars should be interpolated, but got %v", explained)
+	}
+
+	type namedTime time.Time
+	dialect := gorm.NewDialect("sqlite3")
+	for _, c := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{json.RawMessage("ab"), "X'6162'"},
+		{namedTime(time.Date(2015, 1, 2, 0, 0, 0, 0, time.UTC)), "'2015-01-02 00:00:00+00:00'"},
+	} {
+		if literal := dialect.LiteralValue(c.value); literal != c.expected {
+			t.Errorf("Literal of %#v should be %v, but got %v", c.value, c.expected, literal)
+		}
+	}
+}
@@ -61,6 +61,9 @@ type StructField struct {
 	IsIgnored       bool
 	IsScanner       bool
 	HasDefaultValue bool
+	IsCreatable     bool
+	IsUpdatable     bool
+	IsReadable      bool
 	Tag             reflect.StructTag
 	TagSettings     map[string]string
 	Struct          reflect.StructField
@@ -78,6 +81,9 @@ func (structField *StructField) clone() *StructField {
 		IsIgnored:       structField.IsIgnored,
 		IsScanner:       structField.IsScanner,
 		HasDefaultValue: structField.HasDefaultValue,
+		IsCreatable:     structField.IsCreatable,
+		IsUpdatable:     structField.IsUpdatable,
+		IsReadable:      structField.IsReadable,
 		Tag:             structField.Tag,
 		TagSettings:     structField.TagSettings,
 		Struct:          structField.Struct,
@@ -153,6 +159,7 @@ func (scope *Scope) GetModelStruct() *ModelStruct {
 				Tag:         fieldStruct.Tag,
 				TagSettings: parseTagSetting(fieldStruct.Tag),
 			}
+			field.IsCreatable, field.IsUpdatable, field.IsReadable = parsePermissions(field.TagSettings)
 
 			// is ignored field
 			if fieldStruct.Tag.Get("sql") == "-" {
@@ -533,7 +540,7 @@ func (scope *Scope) generateSqlTag(field *StructField) string {
 		var getScannerValue func(reflect.Value)
 		getScannerValue = func(value reflect.Value) {
 			reflectValue = value
-			if _, isScanner := reflect.New(reflectValue.Type()).Interface().(sql.Scanner); isScanner && reflectValue.Kind() == reflect.Struct {
+			if _, isScanner := reflect.New(reflectValue.Type()).Interface().(sql.Scanner); isScanner && reflectValue.Kind() == reflect.Struct && !isJSON(reflectValue) {
 				getScannerValue(reflectValue.Field(0))
 			}
 		}
@@ -565,6 +572,35 @@ func (scope *Scope) generateSqlTag(field *StructField) string {
 	}
 }
 
+// parsePermissions parses field's permissions from tag settings, `<-` limits the writes to `<-:create`, `<-:update` or none with `<-:false`,
+// `->` makes the field read only, and `->:false` excludes it from selects
+func parsePermissions(settings map[string]string) (creatable, updatable, readable bool) {
+	creatable, updatable, readable = true, true, true
+
+	if value, ok := settings["->"]; ok {
+		if strings.ToUpper(value) == "FALSE" {
+			readable = false
+		} else {
+			creatable, updatable = false, false
+		}
+	}
+
+	if value, ok := settings["<-"]; ok {
+		creatable, updatable = false, false
+		for _, permission := range strings.Split(strings.ToUpper(value), ",") {
+			switch strings.TrimSpace(permission) {
+			case "<-":
+				creatable, updatable = true, true
+			case "CREATE":
+				creatable = true
+			case "UPDATE":
+				updatable = true
+			}
+		}
+	}
+	return
+}
+
 func parseTagSetting(tags reflect.StructTag) map[string]string {
 	setting := map[string]string{}
 	for _, str := range []string{tags.Get("sql"), tags.Get("gorm")} {
@@ -1,8 +1,10 @@
 package gorm
 
 import (
+	"encoding/hex"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 )
 
@@ -15,6 +17,10 @@ func (mssql) HasTop() bool {
 }
 
 func (mssql) SqlTag(value reflect.Value, size int, autoIncrease bool) string {
+	if isJSON(value) {
+		return "nvarchar(max)"
+	}
+
 	switch value.Kind() {
 	case reflect.Bool:
 		return "bit"
@@ -50,6 +56,55 @@ func (mssql) SqlTag(value reflect.Value, size int, autoIncrease bool) string {
 	panic(fmt.Sprintf("invalid sql type %s (%s) for mssql", value.Type().Name(), value.Kind().String()))
 }
 
+func (s mssql) UpsertSql(tableName string, primaryKey string, columns []string, values []string, onConflict OnConflict) (string, error) {
+	if len(onConflict.Columns) == 0 {
+		return "", ErrMissingConflict
+	}
+
+	var quotedColumns, excludedColumns, conditions, updates []string
+	for _, column := range columns {
+		quotedColumns = append(quotedColumns, s.Quote(column))
+		excludedColumns = append(excludedColumns, "excluded."+s.Quote(column))
+	}
+
+	for _, column := range onConflict.ConflictColumns() {
+		conditions = append(conditions, fmt.Sprintf("%v.%v = excluded.%v", tableName, s.Quote(column), s.Quote(column)))
+	}
+
+	for _, column := range onConflict.UpdateColumns(columns) {
+		updates = append(updates, fmt.Sprintf("%v = excluded.%v", s.Quote(column), s.Quote(column)))
+	}
+
+	var matched string
+	if len(updates) > 0 {
+		matched = fmt.Sprintf("WHEN MATCHED THEN UPDATE SET %v ", strings.Join(updates, ", "))
+	}
+
+	return fmt.Sprintf(
+		"MERGE INTO %v USING (VALUES %v) AS excluded (%v) ON %v %vWHEN NOT MATCHED THEN INSERT (%v) VALUES (%v);",
+		tableName,
+		strings.Join(values, ","),
+		strings.Join(quotedColumns, ","),
+		strings.Join(conditions, " AND "),
+		matched,
+		strings.Join(quotedColumns, ","),
+		strings.Join(excludedColumns, ","),
+	), nil
+}
+
+func (mssql) SavePointStr(name string) string {
+	return fmt.Sprintf("SAVE TRANSACTION %v", name)
+}
+
+func (mssql) RollbackToSavePointStr(name string) string {
+	return fmt.Sprintf("ROLLBACK TRANSACTION %v", name)
+}
+
+// ReleaseSavePointStr returns empty as mssql releases savepoints only when the transaction ends
+func (mssql) ReleaseSavePointStr(name string) string {
+	return ""
+}
+
 func (s mssql) HasTable(scope *Scope, tableName string) bool {
 	var (
 		count        int
@@ -78,3 +133,41 @@ func (s mssql) CurrentDatabase(scope *Scope) (name string) {
 	s.RawScanString(scope, &name, "SELECT DB_NAME() AS [Current Database]")
 	return
 }
+
+func (mssql) TranslateError(err error) error {
+	// the Number of github.com/denisenkom/go-mssqldb's Error
+	if number, ok := errorNumber(err, "Number"); ok {
+		switch number {
+		case 2601, 2627:
+			return translateError(ErrDuplicatedKey, err)
+		case 547:
+			// 547 is reported for both foreign key and check constraint conflicts
+			if strings.Contains(err.Error(), "CHECK constraint") {
+				return translateError(ErrCheckConstraint, err)
+			}
+			return translateError(ErrForeignKeyViolated, err)
+		case 1205:
+			return translateError(ErrDeadlock, err)
+		case 3960:
+			return translateError(ErrSerialization, err)
+		}
+	}
+	return err
+}
+
+var mssqlLiteralFormat = literalFormat{
+	timeLayout: "2006-01-02T15:04:05.9999999Z07:00",
+	trueValue:  "1",
+	falseValue: "0",
+	bytes: func(b []byte) string {
+		return "0x" + hex.EncodeToString(b)
+	},
+}
+
+func (mssql) LiteralValue(value interface{}) string {
+	return formatLiteral(value, mssqlLiteralFormat)
+}
+
+func (mssql) JSONExtract(scope *Scope, column string, keys []string) string {
+	return fmt.Sprintf("JSON_VALUE(%v, %v)", column, scope.AddToVars(jsonPath(keys)))
+}
@@ -3,6 +3,7 @@ package gorm
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 )
 
@@ -10,7 +11,15 @@ type mysql struct {
 	commonDialect
 }
 
+func (mysql) SupportRowValueComparison() bool {
+	return true
+}
+
 func (mysql) SqlTag(value reflect.Value, size int, autoIncrease bool) string {
+	if isJSON(value) {
+		return "json"
+	}
+
 	switch value.Kind() {
 	case reflect.Bool:
 		return "boolean"
@@ -60,6 +69,27 @@ func (mysql) Quote(key string) string {
 	return fmt.Sprintf("`%s`", key)
 }
 
+func (s mysql) UpsertSql(tableName string, primaryKey string, columns []string, values []string, onConflict OnConflict) (string, error) {
+	var quotedColumns, updates []string
+	for _, column := range columns {
+		quotedColumns = append(quotedColumns, s.Quote(column))
+	}
+
+	for _, column := range onConflict.UpdateColumns(columns) {
+		updates = append(updates, fmt.Sprintf("%v = VALUES(%v)", s.Quote(column), s.Quote(column)))
+	}
+
+	if primaryKey != "" {
+		// make LastInsertId return the conflicted record's primary key when it's updated instead of inserted
+		updates = append(updates, fmt.Sprintf("%v = LAST_INSERT_ID(%v)", s.Quote(primaryKey), s.Quote(primaryKey)))
+	} else if len(updates) == 0 && len(columns) > 0 {
+		// keep the existing record by updating a column to itself, as INSERT IGNORE would ignore other errors also
+		updates = append(updates, fmt.Sprintf("%v = %v", s.Quote(columns[0]), s.Quote(columns[0])))
+	}
+
+	return fmt.Sprintf("INSERT INTO %v (%v) VALUES %v ON DUPLICATE KEY UPDATE %v", tableName, strings.Join(quotedColumns, ","), strings.Join(values, ","), strings.Join(updates, ", ")), nil
+}
+
 func (mysql) SelectFromDummyTable() string {
 	return "FROM DUAL"
 }
@@ -68,3 +98,36 @@ func (s mysql) CurrentDatabase(scope *Scope) (name string) {
 	s.RawScanString(scope, &name, "SELECT DATABASE()")
 	return
 }
+
+func (mysql) TranslateError(err error) error {
+	// the Number of github.com/go-sql-driver/mysql's MySQLError
+	if number, ok := errorNumber(err, "Number"); ok {
+		switch number {
+		case 1062:
+			return translateError(ErrDuplicatedKey, err)
+		case 1451, 1452:
+			return translateError(ErrForeignKeyViolated, err)
+		case 3819:
+			return translateError(ErrCheckConstraint, err)
+		case 1213:
+			return translateError(ErrDeadlock, err)
+		}
+	}
+	return err
+}
+
+var mysqlLiteralFormat = literalFormat{
+	timeLayout:      "2006-01-02 15:04:05.999999",
+	trueValue:       "TRUE",
+	falseValue:      "FALSE",
+	escapeBackslash: true,
+	bytes:           defaultLiteralFormat.bytes,
+}
+
+func (mysql) LiteralValue(value interface{}) string {
+	return formatLiteral(value, mysqlLiteralFormat)
+}
+
+func (mysql) JSONExtract(scope *Scope, column string, keys []string) string {
+	return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%v, %v))", column, scope.AddToVars(jsonPath(keys)))
+}
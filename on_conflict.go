@@ -0,0 +1,43 @@
+package gorm
+
+// OnConflict makes Create an upsert when set with `gorm:on_conflict`, e.g:
+//
+//	db.Set("gorm:on_conflict", gorm.OnConflict{Columns: []string{"email"}, DoUpdates: []string{"name"}}).Create(&user)
+type OnConflict struct {
+	// Columns are the unique columns to detect the conflict with, required by mssql, and by postgres and sqlite3 unless DoNothing
+	Columns []string
+	// DoUpdates are the columns to update with the inserting values, all inserting columns except Columns will be updated if blank
+	DoUpdates []string
+	// DoNothing keeps the existing record untouched
+	DoNothing bool
+}
+
+// ConflictColumns returns the db names of the conflict columns
+func (onConflict OnConflict) ConflictColumns() (columns []string) {
+	for _, column := range onConflict.Columns {
+		columns = append(columns, ToDBName(column))
+	}
+	return
+}
+
+// UpdateColumns returns the db names of the columns to update when conflicted, columns are the inserting columns
+func (onConflict OnConflict) UpdateColumns(columns []string) (updates []string) {
+	if onConflict.DoNothing {
+		return nil
+	}
+
+	if len(onConflict.DoUpdates) > 0 {
+		for _, column := range onConflict.DoUpdates {
+			updates = append(updates, ToDBName(column))
+		}
+		return
+	}
+
+	conflictColumns := onConflict.ConflictColumns()
+	for _, column := range columns {
+		if !strInSlice(column, conflictColumns) {
+			updates = append(updates, column)
+		}
+	}
+	return
+}
@@ -0,0 +1,215 @@
+package gorm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// PageColumn is a column records are paginated by
+type PageColumn struct {
+	// Name is the column's name, it could be qualified with the table name like `users.id`
+	Name string
+	// Desc orders the column descending
+	Desc bool
+}
+
+// Pagination finds pages of records with keyset pagination, records after or before the cursor's record are found with
+// conditions on the ordered columns instead of an offset, e.g:
+//
+//	pagination := gorm.Pagination{Columns: []gorm.PageColumn{{Name: "created_at", Desc: true}, {Name: "id", Desc: true}}, Limit: 20, Cursor: cursor}
+//	db.Where("state = ?", "paid").Paginate(&orders, &pagination)
+//	// pagination.NextCursor and pagination.PrevCursor could be used to find the following and preceding pages
+type Pagination struct {
+	// Columns orders the records, the last one should be unique like the primary key to break ties, none of them should be NULL
+	Columns []PageColumn
+	// Limit is the maximum number of records in a page, all records after the cursor are found if it's less than 1
+	Limit int
+	// Cursor is the NextCursor or PrevCursor of another page, the first page is found if it's blank
+	Cursor string
+
+	// NextCursor is set to the cursor of the following page by Paginate, it's blank for the last page
+	NextCursor string
+	// PrevCursor is set to the cursor of the preceding page by Paginate, it's blank for the first page
+	PrevCursor string
+}
+
+// cursor is encoded as base64 json with the values of the ordered columns of a page's first or last record
+type cursor struct {
+	Prev   bool              `json:"p,omitempty"`
+	Values []json.RawMessage `json:"v"`
+}
+
+// Paginate find a page of records into out, a pointer to a slice, then set the pagination's NextCursor and PrevCursor
+func (s *DB) Paginate(out interface{}, pagination *Pagination) *DB {
+	db := s.clone()
+	scope := db.NewScope(out)
+
+	destType := reflect.Indirect(reflect.ValueOf(out)).Type()
+	if destType.Kind() != reflect.Slice {
+		db.AddError(errors.New("unsupported destination, should be slice"))
+		return db
+	}
+
+	elemType := destType.Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	if len(pagination.Columns) == 0 {
+		db.AddError(errors.New("pagination should have columns"))
+		return db
+	}
+
+	var fields []*Field
+	elemScope := scope.New(reflect.New(elemType).Interface())
+	for _, column := range pagination.Columns {
+		name := column.Name
+		if idx := strings.LastIndex(name, "."); idx >= 0 {
+			name = name[idx+1:]
+		}
+
+		field, ok := elemScope.FieldByName(name)
+		if !ok {
+			db.AddError(fmt.Errorf("pagination column %v isn't a field of %v", column.Name, elemType))
+			return db
+		}
+		fields = append(fields, field)
+	}
+
+	var c cursor
+	if pagination.Cursor != "" {
+		values, err := decodeCursor(pagination.Cursor, fields, &c)
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+		sql, vars := keysetCondition(scope, pagination.Columns, c.Prev, values)
+		db = db.Where(sql, vars...)
+	}
+
+	for idx, column := range pagination.Columns {
+		direction := "ASC"
+		if column.Desc != c.Prev {
+			direction = "DESC"
+		}
+		db = db.Order(fmt.Sprintf("%v %v", scope.Quote(column.Name), direction), idx == 0)
+	}
+
+	if pagination.Limit > 0 {
+		db = db.Limit(pagination.Limit + 1)
+	}
+
+	if db = db.Find(out); db.Error != nil {
+		return db
+	}
+
+	dest := reflect.Indirect(reflect.ValueOf(out))
+	hasMore := pagination.Limit > 0 && dest.Len() > pagination.Limit
+	if hasMore {
+		dest.Set(dest.Slice(0, pagination.Limit))
+	}
+
+	// records before the cursor are found in reverse order
+	if c.Prev {
+		for i, j := 0, dest.Len()-1; i < j; i, j = i+1, j-1 {
+			first, last := dest.Index(i).Interface(), dest.Index(j).Interface()
+			dest.Index(i).Set(reflect.ValueOf(last))
+			dest.Index(j).Set(reflect.ValueOf(first))
+		}
+	}
+
+	pagination.NextCursor, pagination.PrevCursor = "", ""
+	if dest.Len() > 0 {
+		var err error
+		if hasMore || c.Prev {
+			pagination.NextCursor, err = encodeCursor(scope, dest.Index(dest.Len()-1), fields, false)
+		}
+		if err == nil && (hasMore && c.Prev || pagination.Cursor != "" && !c.Prev) {
+			pagination.PrevCursor, err = encodeCursor(scope, dest.Index(0), fields, true)
+		}
+		db.AddError(err)
+	}
+	return db
+}
+
+// keysetCondition builds the condition to find records after, or before if backward, the record with values,
+// like `(a, b) > (?, ?)` or `(a > ?) OR (a = ? AND b > ?)` if columns are ordered in different directions or row values aren't supported
+func keysetCondition(scope *Scope, columns []PageColumn, backward bool, values []interface{}) (string, []interface{}) {
+	operator := func(column PageColumn) string {
+		if column.Desc != backward {
+			return "<"
+		}
+		return ">"
+	}
+
+	sameDirection := true
+	for _, column := range columns {
+		sameDirection = sameDirection && column.Desc == columns[0].Desc
+	}
+
+	if len(columns) > 1 && sameDirection && scope.Dialect().SupportRowValueComparison() {
+		var quotedColumns, marks []string
+		for _, column := range columns {
+			quotedColumns = append(quotedColumns, scope.Quote(column.Name))
+			marks = append(marks, "?")
+		}
+		return fmt.Sprintf("(%v) %v (%v)", strings.Join(quotedColumns, ", "), operator(columns[0]), strings.Join(marks, ", ")), values
+	}
+
+	var (
+		conditions []string
+		vars       []interface{}
+	)
+	for idx, column := range columns {
+		var sqls []string
+		for i := 0; i < idx; i++ {
+			sqls = append(sqls, fmt.Sprintf("%v = ?", scope.Quote(columns[i].Name)))
+			vars = append(vars, values[i])
+		}
+		sqls = append(sqls, fmt.Sprintf("%v %v ?", scope.Quote(column.Name), operator(column)))
+		vars = append(vars, values[idx])
+		conditions = append(conditions, "("+strings.Join(sqls, " AND ")+")")
+	}
+	return strings.Join(conditions, " OR "), vars
+}
+
+// decodeCursor decodes str into c, and returns its values converted to the types of the ordered fields
+func decodeCursor(str string, fields []*Field, c *cursor) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(str)
+	if err == nil {
+		err = json.Unmarshal(data, c)
+	}
+	if err != nil || len(c.Values) != len(fields) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, len(fields))
+	for idx, field := range fields {
+		value := reflect.New(field.Struct.Type)
+		if err := json.Unmarshal(c.Values[idx], value.Interface()); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[idx] = value.Elem().Interface()
+	}
+	return values, nil
+}
+
+func encodeCursor(scope *Scope, elem reflect.Value, fields []*Field, prev bool) (string, error) {
+	elemFields := scope.New(reflect.Indirect(elem).Addr().Interface()).Fields()
+
+	c := cursor{Prev: prev}
+	for _, field := range fields {
+		data, err := json.Marshal(elemFields[field.DBName].Field.Interface())
+		if err != nil {
+			return "", err
+		}
+		c.Values = append(c.Values, data)
+	}
+
+	data, err := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data), err
+}
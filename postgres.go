@@ -3,11 +3,13 @@ package gorm
 import (
 	"database/sql"
 	"database/sql/driver"
+	"encoding/hex"
 	"fmt"
 	"reflect"
 	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/lib/pq/hstore"
 )
 
@@ -23,7 +25,15 @@ func (postgres) SupportLastInsertId() bool {
 	return false
 }
 
-func (postgres) SqlTag(value reflect.Value, size int, autoIncrease bool) string {
+func (postgres) SupportRowValueComparison() bool {
+	return true
+}
+
+func (s postgres) SqlTag(value reflect.Value, size int, autoIncrease bool) string {
+	if isJSON(value) {
+		return "jsonb"
+	}
+
 	switch value.Kind() {
 	case reflect.Bool:
 		return "boolean"
@@ -57,6 +67,9 @@ func (postgres) SqlTag(value reflect.Value, size int, autoIncrease bool) string
 			return "bytea"
 		} else if isUUID(value) {
 			return "uuid"
+		} else if isPostgresArray(value) {
+			// pq's array types are stored as arrays of their elements' type, like text[] for StringArray
+			return s.SqlTag(reflect.Indirect(reflect.New(value.Type().Elem())), 0, false) + "[]"
 		}
 	}
 	panic(fmt.Sprintf("invalid sql type %s (%s) for postgres", value.Type().Name(), value.Kind().String()))
@@ -77,6 +90,15 @@ func isUUID(value reflect.Value) bool {
 	return "uuid" == lower || "guid" == lower
 }
 
+// isPostgresArray returns true for pq's array types, which could be saved and scanned as postgres arrays
+func isPostgresArray(value reflect.Value) bool {
+	switch value.Interface().(type) {
+	case pq.StringArray, pq.Int64Array, pq.BoolArray, pq.Float64Array:
+		return true
+	}
+	return false
+}
+
 func (s postgres) ReturningStr(tableName, key string) string {
 	return fmt.Sprintf("RETURNING %v.%v", tableName, key)
 }
@@ -152,3 +174,81 @@ func (h *Hstore) Scan(value interface{}) error {
 
 	return nil
 }
+
+// StringArray, Int64Array, BoolArray and Float64Array are postgres arrays, created as text[], bigint[], boolean[] and numeric[],
+// they are pq's array types, so other slices could be wrapped with pq.Array to be saved as arrays
+type (
+	StringArray  = pq.StringArray
+	Int64Array   = pq.Int64Array
+	BoolArray    = pq.BoolArray
+	Float64Array = pq.Float64Array
+)
+
+// ArrayContains builds a `column @> values` condition, which matches records whose postgres array contains all values, e.g:
+//
+//	db.Where(gorm.ArrayContains("tags", []string{"go", "orm"})).Find(&posts)
+func ArrayContains(column string, values interface{}) *Condition {
+	return &Condition{Column: column, Operator: "@>", Values: []interface{}{postgresArray(values)}}
+}
+
+// ArrayOverlaps builds a `column && values` condition, which matches records whose postgres array contains any of values
+func ArrayOverlaps(column string, values interface{}) *Condition {
+	return &Condition{Column: column, Operator: "&&", Values: []interface{}{postgresArray(values)}}
+}
+
+// ArrayAny builds a `value = ANY(column)` condition, which matches records whose postgres array contains value
+func ArrayAny(column string, value interface{}) *Condition {
+	return &Condition{Column: column, Operator: "ANY", Values: []interface{}{value}}
+}
+
+// postgresArray wraps slices with pq.Array to bind them as a single postgres array, instead of expanding them like other conditions
+func postgresArray(values interface{}) interface{} {
+	if _, ok := values.(driver.Valuer); ok {
+		return values
+	}
+	if _, ok := values.([]byte); !ok && reflect.ValueOf(values).Kind() == reflect.Slice {
+		return pq.Array(values)
+	}
+	return values
+}
+
+func (postgres) TranslateError(err error) error {
+	// the Code of github.com/lib/pq's Error
+	if code, ok := errorCode(err, "Code"); ok {
+		switch code {
+		case "23505":
+			return translateError(ErrDuplicatedKey, err)
+		case "23503":
+			return translateError(ErrForeignKeyViolated, err)
+		case "23514":
+			return translateError(ErrCheckConstraint, err)
+		case "40P01":
+			return translateError(ErrDeadlock, err)
+		case "40001":
+			return translateError(ErrSerialization, err)
+		}
+	}
+	return err
+}
+
+var postgresLiteralFormat = literalFormat{
+	timeLayout: "2006-01-02 15:04:05.999999-07:00",
+	trueValue:  "true",
+	falseValue: "false",
+	bytes: func(b []byte) string {
+		return `'\x` + hex.EncodeToString(b) + "'"
+	},
+}
+
+// JSONExtract extracts the value at keys as text with `#>>`, which works for both json and jsonb columns
+func (postgres) JSONExtract(scope *Scope, column string, keys []string) string {
+	var quotedKeys []string
+	for _, key := range keys {
+		quotedKeys = append(quotedKeys, `"`+strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(key)+`"`)
+	}
+	return fmt.Sprintf("(%v #>> %v)", column, scope.AddToVars("{"+strings.Join(quotedKeys, ",")+"}"))
+}
+
+func (postgres) LiteralValue(value interface{}) string {
+	return formatLiteral(value, postgresLiteralFormat)
+}
@@ -96,6 +96,15 @@ func Preload(scope *Scope) {
 
 }
 
+// preloadDB returns a new DB to query associations, which includes soft deleted records if scope is unscoped
+func (scope *Scope) preloadDB() *DB {
+	db := scope.NewDB()
+	if scope.Search.Unscoped {
+		db = db.Unscoped()
+	}
+	return db
+}
+
 func makeSlice(typ reflect.Type) interface{} {
 	if typ.Kind() == reflect.Slice {
 		typ = typ.Elem()
@@ -115,7 +124,7 @@ func (scope *Scope) handleHasOnePreload(field *Field, conditions []interface{})
 	}
 
 	results := makeSlice(field.Struct.Type)
-	scope.Err(scope.NewDB().Where(fmt.Sprintf("%v IN (%v)", toQueryCondition(scope, relation.ForeignDBNames), toQueryMarks(primaryKeys)), toQueryValues(primaryKeys)...).Find(results, conditions...).Error)
+	scope.Err(scope.preloadDB().Where(fmt.Sprintf("%v IN (%v)", toQueryCondition(scope, relation.ForeignDBNames), toQueryMarks(primaryKeys)), toQueryValues(primaryKeys)...).Find(results, conditions...).Error)
 	resultValues := reflect.Indirect(reflect.ValueOf(results))
 
 	for i := 0; i < resultValues.Len(); i++ {
@@ -146,7 +155,7 @@ func (scope *Scope) handleHasManyPreload(field *Field, conditions []interface{})
 	}
 
 	results := makeSlice(field.Struct.Type)
-	scope.Err(scope.NewDB().Where(fmt.Sprintf("%v IN (%v)", toQueryCondition(scope, relation.ForeignDBNames), toQueryMarks(primaryKeys)), toQueryValues(primaryKeys)...).Find(results, conditions...).Error)
+	scope.Err(scope.preloadDB().Where(fmt.Sprintf("%v IN (%v)", toQueryCondition(scope, relation.ForeignDBNames), toQueryMarks(primaryKeys)), toQueryValues(primaryKeys)...).Find(results, conditions...).Error)
 	resultValues := reflect.Indirect(reflect.ValueOf(results))
 
 	if scope.IndirectValue().Kind() == reflect.Slice {
@@ -176,7 +185,7 @@ func (scope *Scope) handleBelongsToPreload(field *Field, conditions []interface{
 	}
 
 	results := makeSlice(field.Struct.Type)
-	scope.Err(scope.NewDB().Where(fmt.Sprintf("%v IN (%v)", toQueryCondition(scope, relation.AssociationForeignDBNames), toQueryMarks(primaryKeys)), toQueryValues(primaryKeys)...).Find(results, conditions...).Error)
+	scope.Err(scope.preloadDB().Where(fmt.Sprintf("%v IN (%v)", toQueryCondition(scope, relation.AssociationForeignDBNames), toQueryMarks(primaryKeys)), toQueryValues(primaryKeys)...).Find(results, conditions...).Error)
 	resultValues := reflect.Indirect(reflect.ValueOf(results))
 
 	for i := 0; i < resultValues.Len(); i++ {
@@ -216,7 +225,9 @@ func (scope *Scope) handleManyToManyPreload(field *Field, conditions []interface
 		sourceKeys = append(sourceKeys, key.DBName)
 	}
 
-	db := scope.NewDB().Table(scope.New(reflect.New(destType).Interface()).TableName()).Select("*")
+	// query with the destination as model, so its soft deleted records are excluded
+	destination := reflect.New(destType).Interface()
+	db := scope.preloadDB().Model(destination).Table(scope.New(destination).TableName()).Select("*")
 
 	preloadJoinDB := joinTableHandler.JoinWith(joinTableHandler, db, scope.Value)
 
@@ -225,7 +236,7 @@ func (scope *Scope) handleManyToManyPreload(field *Field, conditions []interface
 	}
 	rows, err := preloadJoinDB.Rows()
 
-	if scope.Err(err) != nil {
+	if scope.Err(err) != nil || rows == nil {
 		return
 	}
 	defer rows.Close()
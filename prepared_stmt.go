@@ -0,0 +1,200 @@
+package gorm
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+)
+
+type stmtKey struct {
+	db  sqlCommon
+	sql string
+}
+
+// maxPreparedStmts limits the prepared statements cached for a DB, statements are executed without preparing when the cache is full
+const maxPreparedStmts = 1000
+
+// stmtCache caches prepared statements by connection pool and sql
+type stmtCache struct {
+	mutex sync.RWMutex
+	stmts map[stmtKey]*sql.Stmt
+}
+
+func newStmtCache() *stmtCache {
+	return &stmtCache{stmts: map[stmtKey]*sql.Stmt{}}
+}
+
+// cacheable returns true for statements worth preparing, transaction control statements like savepoints and DDL are executed directly
+func cacheable(query string) bool {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT", "INSERT", "UPDATE", "DELETE", "WITH", "MERGE", "REPLACE":
+		return true
+	}
+	return false
+}
+
+// prepare returns the cached prepared statement of query, or nil if query shouldn't be cached, it's prepared without holding the lock,
+// so statements are prepared concurrently, and the statement prepared later is closed if another one is cached meanwhile
+func (cache *stmtCache) prepare(ctx context.Context, db sqlCommon, query string) (*sql.Stmt, error) {
+	if !cacheable(query) {
+		return nil, nil
+	}
+
+	key := stmtKey{db: db, sql: query}
+
+	cache.mutex.RLock()
+	stmt, ok := cache.stmts[key]
+	full := len(cache.stmts) >= maxPreparedStmts
+	cache.mutex.RUnlock()
+	if ok || full {
+		return stmt, nil
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cached, ok := cache.stmts[key]; ok {
+		stmt.Close()
+		return cached, nil
+	}
+
+	if len(cache.stmts) >= maxPreparedStmts {
+		stmt.Close()
+		return nil, nil
+	}
+	cache.stmts[key] = stmt
+	return stmt, nil
+}
+
+// cached returns the prepared statement of query cached for db, it never prepares statements
+func (cache *stmtCache) cached(db sqlCommon, query string) *sql.Stmt {
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+	return cache.stmts[stmtKey{db: db, sql: query}]
+}
+
+func (cache *stmtCache) close() (err error) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	for key, stmt := range cache.stmts {
+		if e := stmt.Close(); e != nil {
+			err = e
+		}
+		delete(cache.stmts, key)
+	}
+	return
+}
+
+// preparedStmtDB runs statements in a connection pool with cached prepared statements
+type preparedStmtDB struct {
+	sqlCommon
+	cache *stmtCache
+}
+
+func (db *preparedStmtDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := db.cache.prepare(ctx, db.sqlCommon, query)
+	if err != nil {
+		return nil, err
+	} else if stmt == nil {
+		return db.sqlCommon.ExecContext(ctx, query, args...)
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (db *preparedStmtDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := db.cache.prepare(ctx, db.sqlCommon, query)
+	if err != nil {
+		return nil, err
+	} else if stmt == nil {
+		return db.sqlCommon.QueryContext(ctx, query, args...)
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+func (db *preparedStmtDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	stmt, err := db.cache.prepare(ctx, db.sqlCommon, query)
+	if err != nil || stmt == nil {
+		// sql.Row can't be built with an error, run the query directly to get the same error
+		return db.sqlCommon.QueryRowContext(ctx, query, args...)
+	}
+	return stmt.QueryRowContext(ctx, args...)
+}
+
+// preparedStmtTx runs statements in a transaction with the connection pool's cached prepared statements,
+// which are bound to the transaction once, statements not cached yet are prepared on the transaction's connection,
+// as the pool may have no other connection to prepare them, all of them are closed when the transaction ends
+type preparedStmtTx struct {
+	*sql.Tx
+	pool  sqlCommon
+	cache *stmtCache
+	mutex sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+func newPreparedStmtTx(tx *sql.Tx, pool sqlCommon, cache *stmtCache) *preparedStmtTx {
+	return &preparedStmtTx{Tx: tx, pool: pool, cache: cache, stmts: map[string]*sql.Stmt{}}
+}
+
+func (tx *preparedStmtTx) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	if stmt, ok := tx.stmts[query]; ok {
+		return stmt, nil
+	}
+
+	if !cacheable(query) {
+		return nil, nil
+	}
+
+	var stmt *sql.Stmt
+	if cached := tx.cache.cached(tx.pool, query); cached != nil {
+		stmt = tx.Tx.StmtContext(ctx, cached)
+	} else {
+		var err error
+		if stmt, err = tx.Tx.PrepareContext(ctx, query); err != nil {
+			return nil, err
+		}
+	}
+	tx.stmts[query] = stmt
+	return stmt, nil
+}
+
+func (tx *preparedStmtTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := tx.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	} else if stmt == nil {
+		return tx.Tx.ExecContext(ctx, query, args...)
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (tx *preparedStmtTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := tx.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	} else if stmt == nil {
+		return tx.Tx.QueryContext(ctx, query, args...)
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+func (tx *preparedStmtTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	stmt, err := tx.stmt(ctx, query)
+	if err != nil || stmt == nil {
+		return tx.Tx.QueryRowContext(ctx, query, args...)
+	}
+	return stmt.QueryRowContext(ctx, args...)
+}
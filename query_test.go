@@ -1,8 +1,11 @@
 package gorm_test
 
 import (
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/jinzhu/gorm"
 	"github.com/jinzhu/now"
@@ -462,6 +465,364 @@ func TestNot(t *testing.T) {
 	}
 }
 
+func TestGroupConditions(t *testing.T) {
+	user1 := User{Name: "GroupUser1", Age: 1}
+	user2 := User{Name: "GroupUser2", Age: 10}
+	user3 := User{Name: "GroupUser3", Age: 20}
+	DB.Save(&user1).Save(&user2).Save(&user3)
+
+	var users []User
+	DB.Where(DB.Where("name = ?", user1.Name).Or("name = ?", user3.Name)).Where("age > ?", 5).Find(&users)
+	if len(users) != 1 || users[0].Name != user3.Name {
+		t.Errorf("Should find user3 with grouped conditions, but got %v", len(users))
+	}
+
+	DB.Where("name LIKE ?", "GroupUser%").Not(DB.Where("age < ?", 5).Or("age > ?", 15)).Find(&users)
+	if len(users) != 1 || users[0].Name != user2.Name {
+		t.Errorf("Should find user2 with negated grouped conditions, but got %v", len(users))
+	}
+
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where(tx.Where("age = ?", 1).Or("age = ?", 2)).Where("name = ?", "group").Find(&[]User{})
+	})
+	if !strings.Contains(sql, "((age = 1) OR (age = 2)) AND (name = 'group')") {
+		t.Errorf("Grouped conditions should be wrapped in parentheses, but got %v", sql)
+	}
+}
+
+func TestConditionHelpers(t *testing.T) {
+	user1 := User{Name: "HelperUser1", Age: 1}
+	user2 := User{Name: "HelperUser2", Age: 10}
+	user3 := User{Name: "HelperUser3", Age: 20}
+	DB.Save(&user1).Save(&user2).Save(&user3)
+
+	scope := DB.Where(gorm.Like("name", "HelperUser%"))
+	tests := []struct {
+		condition interface{}
+		names     []string
+	}{
+		{gorm.Eq("age", 10), []string{user2.Name}},
+		{gorm.Neq("age", 10), []string{user1.Name, user3.Name}},
+		{gorm.Gt("age", 10), []string{user3.Name}},
+		{gorm.Gte("age", 10), []string{user2.Name, user3.Name}},
+		{gorm.Lt("age", 10), []string{user1.Name}},
+		{gorm.Lte("age", 10), []string{user1.Name, user2.Name}},
+		{gorm.In("name", []string{user1.Name, user3.Name}), []string{user1.Name, user3.Name}},
+		{gorm.In("age", 1, 20), []string{user1.Name, user3.Name}},
+		{gorm.In("age", []int{}), nil},
+		{gorm.Between("age", 5, 25), []string{user2.Name, user3.Name}},
+		{gorm.IsNull("users.name"), nil},
+		{gorm.Eq("name", nil), nil},
+		{&gorm.Condition{Column: "age", Operator: ">", Values: []interface{}{5}}, []string{user2.Name, user3.Name}},
+		{&gorm.Condition{Column: "name", Operator: "IS NOT NULL"}, []string{user1.Name, user2.Name, user3.Name}},
+	}
+
+	for _, test := range tests {
+		var names []string
+		scope.Model(&User{}).Where(test.condition).Order("age").Pluck("name", &names)
+		if !reflect.DeepEqual(names, test.names) {
+			t.Errorf("Condition %#v should find %v, but got %v", test.condition, test.names, names)
+		}
+	}
+
+	var names []string
+	scope.Model(&User{}).Not(gorm.Gt("age", 5)).Or(gorm.Eq("name", user3.Name)).Order("age").Pluck("name", &names)
+	if !reflect.DeepEqual(names, []string{user1.Name, user3.Name}) {
+		t.Errorf("Negated conditions should find user1 and user3, but got %v", names)
+	}
+
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where(gorm.Eq("users.name", "helper")).Find(&[]User{})
+	})
+	if !strings.Contains(sql, DB.NewScope(&User{}).Quote("users.name")+" = 'helper'") {
+		t.Errorf("Condition columns should be quoted, but got %v", sql)
+	}
+
+	for _, condition := range []*gorm.Condition{
+		{Column: "age", Operator: "BETWEEN", Values: []interface{}{1}},
+		{Column: "age", Operator: "BETWEEN"},
+		{Column: "age", Operator: "ANY"},
+	} {
+		if err := DB.Where(condition).Find(&[]User{}).Error; err == nil {
+			t.Errorf("Should get error when %v condition has wrong number of values", condition.Operator)
+		}
+	}
+
+	if err := DB.Where(gorm.Eq("name", invalidValuer{})).Find(&[]User{}).Error; err == nil || !strings.Contains(err.Error(), "invalid valuer") {
+		t.Errorf("Should return the error of the condition's valuer, but got %v", err)
+	}
+}
+
+type invalidValuer struct{}
+
+func (invalidValuer) Value() (driver.Value, error) {
+	return nil, errors.New("invalid valuer")
+}
+
+func TestSubQuery(t *testing.T) {
+	user1 := User{Name: "SubQueryUser1", Age: 10, Emails: []Email{{Email: "subquery1@example.com"}}}
+	user2 := User{Name: "SubQueryUser2", Age: 20, Emails: []Email{{Email: "subquery2@example.org"}}}
+	user3 := User{Name: "SubQueryUser3", Age: 30}
+	DB.Save(&user1).Save(&user2).Save(&user3)
+
+	var users []User
+	emails := DB.Model(&Email{}).Select("user_id").Where("email LIKE ?", "subquery%")
+	DB.Where("name LIKE ?", "SubQueryUser%").Where("id IN (?)", emails).Where("age > ?", 15).Find(&users)
+	if len(users) != 1 || users[0].Name != user2.Name {
+		t.Errorf("Should find user2 with subquery, but got %v", len(users))
+	}
+
+	DB.Where(gorm.In("id", emails)).Where(gorm.Like("name", "SubQueryUser%")).Order("age").Find(&users)
+	if len(users) != 2 || users[0].Name != user1.Name {
+		t.Errorf("Should find user1 and user2 with subquery condition, but got %v", len(users))
+	}
+
+	DB.Where("name LIKE ? AND age > ?", "SubQueryUser%", DB.Table("users").Select("AVG(age)").Where("name LIKE ?", "SubQueryUser%")).Find(&users)
+	if len(users) != 1 || users[0].Name != user3.Name {
+		t.Errorf("Should find user3 with subquery as value, but got %v", len(users))
+	}
+
+	var names []string
+	DB.Table("(?) AS u", DB.Model(&User{}).Select("name, age").Where("name LIKE ?", "SubQueryUser%")).Where("u.age >= ?", 20).Order("u.age").Pluck("name", &names)
+	if !reflect.DeepEqual(names, []string{user2.Name, user3.Name}) {
+		t.Errorf("Should find user2 and user3 from subquery, but got %v", names)
+	}
+
+	var count int
+	DB.Table("(?) AS u", DB.Model(&User{}).Select("name").Where("name LIKE ?", "SubQueryUser%")).Count(&count)
+	if count != 3 {
+		t.Errorf("Should count 3 users from subquery, but got %v", count)
+	}
+
+	sql, vars := DB.DryRun().Table("(?) AS u", DB.Model(&User{}).Where("age > ?", 10)).Where("u.name = ?", "jinzhu").Find(&[]User{}).Statement()
+	if len(vars) != 2 || vars[0] != 10 || vars[1] != "jinzhu" {
+		t.Errorf("Subquery vars should be merged in order, but got %v for %v", vars, sql)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		DB.Save(&User{Name: fmt.Sprintf("PaginateUser%v", i), Age: int64(i / 3)})
+	}
+	scopedb := DB.Where("name LIKE ?", "PaginateUser%")
+
+	for _, columns := range [][]gorm.PageColumn{
+		{{Name: "age", Desc: true}, {Name: "users.id", Desc: true}},
+		{{Name: "age"}, {Name: "id", Desc: true}},
+	} {
+		var expected []User
+		order := "age DESC, id DESC"
+		if !columns[0].Desc {
+			order = "age, id DESC"
+		}
+		scopedb.Order(order).Find(&expected)
+
+		var (
+			pages      [][]User
+			pagination = gorm.Pagination{Columns: columns, Limit: 4}
+		)
+		for {
+			var users []User
+			if err := scopedb.Paginate(&users, &pagination).Error; err != nil {
+				t.Fatalf("No error should happen when paginate, but got %v", err)
+			}
+			pages = append(pages, users)
+			if pagination.NextCursor == "" || len(pages) > 5 {
+				break
+			}
+			pagination.Cursor = pagination.NextCursor
+		}
+
+		var found []User
+		for _, page := range pages {
+			found = append(found, page...)
+		}
+		if len(pages) != 3 || len(found) != len(expected) {
+			t.Fatalf("Should find %v users in 3 pages, but got %v in %v pages", len(expected), len(found), len(pages))
+		}
+		for idx := range found {
+			if found[idx].Id != expected[idx].Id {
+				t.Errorf("Paginated users should be ordered by %v, but got %v at %v", order, found[idx].Name, idx)
+			}
+		}
+
+		pagination.Cursor = pagination.PrevCursor
+		var users []User
+		scopedb.Paginate(&users, &pagination)
+		if len(users) != 4 || users[0].Id != pages[1][0].Id || users[3].Id != pages[1][3].Id {
+			t.Errorf("Previous page should be the second page")
+		}
+
+		pagination.Cursor = pagination.PrevCursor
+		scopedb.Paginate(&users, &pagination)
+		if len(users) != 4 || users[0].Id != pages[0][0].Id || pagination.PrevCursor != "" || pagination.NextCursor == "" {
+			t.Errorf("Previous page should be the first page")
+		}
+	}
+
+	pagination := gorm.Pagination{Columns: []gorm.PageColumn{{Name: "id"}}, Limit: 4, Cursor: "invalid"}
+	if err := scopedb.Paginate(&[]User{}, &pagination).Error; err != gorm.ErrInvalidCursor {
+		t.Errorf("Should return ErrInvalidCursor for invalid cursor, but got %v", err)
+	}
+}
+
+func TestFindInBatches(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		DB.Save(&User{Name: fmt.Sprintf("BatchUser%v", i), Age: int64(i)})
+	}
+
+	var (
+		users   []User
+		batches []int
+		names   []string
+	)
+	result := DB.Where("name LIKE ?", "BatchUser%").FindInBatches(&users, 4, func(tx *gorm.DB, batch int) error {
+		batches = append(batches, batch)
+		if int(tx.RowsAffected) != len(users) {
+			t.Errorf("RowsAffected should be the size of the batch")
+		}
+		for _, user := range users {
+			names = append(names, user.Name)
+		}
+		return tx.Model(&User{}).Where("id IN (?)", []int64{users[0].Id, users[len(users)-1].Id}).UpdateColumn("age", 100).Error
+	})
+
+	if result.Error != nil || result.RowsAffected != 10 {
+		t.Errorf("Should find 10 users in batches, but got %v, %v", result.Error, result.RowsAffected)
+	}
+
+	if !reflect.DeepEqual(batches, []int{1, 2, 3}) || len(names) != 10 || names[0] != "BatchUser0" || names[9] != "BatchUser9" {
+		t.Errorf("Should find users in 3 batches ordered by primary key, but got %v, %v", batches, names)
+	}
+
+	var count int
+	DB.Model(&User{}).Where("name LIKE ? AND age = ?", "BatchUser%", 100).Count(&count)
+	if count != 6 {
+		t.Errorf("Records should be updated in batches, but got %v", count)
+	}
+
+	stop := errors.New("stop")
+	batches = nil
+	result = DB.Where("name LIKE ?", "BatchUser%").FindInBatches(&users, 4, func(tx *gorm.DB, batch int) error {
+		batches = append(batches, batch)
+		return stop
+	})
+	if result.Error != stop || len(batches) != 1 {
+		t.Errorf("Should stop finding batches after error, but got %v, %v", result.Error, batches)
+	}
+}
+
+func TestScanRows(t *testing.T) {
+	user1 := User{Name: "ScanRowsUser1", Age: 1}
+	user2 := User{Name: "ScanRowsUser2", Age: 10}
+	DB.Save(&user1).Save(&user2)
+
+	rows, err := DB.Model(&User{}).Where("name LIKE ?", "ScanRowsUser%").Order("age").Rows()
+	if err != nil {
+		t.Fatalf("No error should happen when query rows, but got %v", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		if err := DB.ScanRows(rows, &user); err != nil {
+			t.Errorf("No error should happen when scan rows, but got %v", err)
+		}
+		users = append(users, user)
+	}
+
+	if len(users) != 2 || users[0].Name != user1.Name || users[0].Id != user1.Id || users[1].Age != user2.Age {
+		t.Errorf("Should scan rows into users, but got %#v", users)
+	}
+
+	type result struct {
+		Name string
+		Age  int
+	}
+
+	rows, _ = DB.Table("users").Select("name, age").Where("name = ?", user2.Name).Rows()
+	defer rows.Close()
+	var res result
+	for rows.Next() {
+		DB.ScanRows(rows, &res)
+	}
+	if res.Name != user2.Name || res.Age != 10 {
+		t.Errorf("Should scan rows into any struct, but got %#v", res)
+	}
+}
+
+func TestScanIntoMapsAndValues(t *testing.T) {
+	company := Company{Name: "scan_company"}
+	DB.Save(&company)
+	companyID := int(company.Id)
+
+	user1 := User{Name: "ScanMapUser1", Age: 1, CompanyID: &companyID}
+	user2 := User{Name: "ScanMapUser2", Age: 10}
+	DB.Save(&user1).Save(&user2)
+	scopedb := DB.Table("users").Where("name LIKE ?", "ScanMapUser%")
+
+	var result map[string]interface{}
+	if err := scopedb.Select("name, age").Order("age").Limit(1).Scan(&result).Error; err != nil {
+		t.Errorf("No error should happen when scan into map, but got %v", err)
+	}
+	if len(result) != 2 || result["name"] != user1.Name || fmt.Sprint(result["age"]) != "1" {
+		t.Errorf("Should scan into map, but got %#v", result)
+	}
+
+	if err := DB.Table("users").Where("name = ?", "none").Scan(&map[string]interface{}{}).Error; err != gorm.RecordNotFound {
+		t.Errorf("Should return RecordNotFound when scan into map, but got %v", err)
+	}
+
+	var results []map[string]interface{}
+	scopedb.Select("name, company_id").Order("age").Find(&results)
+	if len(results) != 2 || results[1]["name"] != user2.Name || fmt.Sprint(results[0]["company_id"]) != fmt.Sprint(companyID) || results[1]["company_id"] != nil {
+		t.Errorf("Should find into slice of maps, but got %#v", results)
+	}
+
+	var names []string
+	scopedb.Select("name").Order("age DESC").Scan(&names)
+	if !reflect.DeepEqual(names, []string{user2.Name, user1.Name}) {
+		t.Errorf("Should scan into slice of strings, but got %v", names)
+	}
+
+	var ages []*int64
+	scopedb.Select("age").Order("age").Find(&ages)
+	if len(ages) != 2 || *ages[0] != 1 || *ages[1] != 10 {
+		t.Errorf("Should find into slice of pointers, but got %v", ages)
+	}
+
+	var total int64
+	scopedb.Select("SUM(age)").Scan(&total)
+	if total != 11 {
+		t.Errorf("Should scan into value, but got %v", total)
+	}
+
+	type companyResult struct {
+		Name string
+	}
+	type userResult struct {
+		Name    string
+		Age     int
+		Company *companyResult
+	}
+
+	var userResults []userResult
+	DB.Table("users").Where("users.name LIKE ?", "ScanMapUser%").Select("users.name, users.age, companies.name AS company__name").Joins("LEFT JOIN companies ON companies.id = users.company_id").Order("users.age").Scan(&userResults)
+	if len(userResults) != 2 || userResults[0].Company == nil || userResults[0].Company.Name != company.Name || userResults[1].Company != nil {
+		t.Errorf("Should scan aliased columns into nested structs, but got %#v", userResults)
+	}
+
+	rows, _ := scopedb.Select("name, age").Order("age").Rows()
+	defer rows.Close()
+	for rows.Next() {
+		row := map[string]interface{}{}
+		if err := DB.ScanRows(rows, &row); err != nil || row["name"] == nil {
+			t.Errorf("Should scan rows into map, but got %v, %#v", err, row)
+		}
+	}
+}
+
 func TestFillSmallerStruct(t *testing.T) {
 	user1 := User{Name: "SmallerUser", Age: 100}
 	DB.Save(&user1)
@@ -0,0 +1,96 @@
+package gorm
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// Replica is a read replica passed to Open after the primary database's source, queries out of transactions are routed to replicas, e.g:
+//
+//	db, err := gorm.Open("postgres", primaryDSN, gorm.Replica{Source: replicaDSN1}, gorm.Replica{Source: replicaDSN2, Weight: 2})
+type Replica struct {
+	// Source is the replica's data source name opened with the primary's driver, or an opened *sql.DB, which is not closed by DB.Close
+	Source interface{}
+	// Weight is the relative weight to route queries to the replica, replicas with the same weight are used in round-robin
+	Weight int
+}
+
+type replica struct {
+	db      sqlCommon
+	weight  int
+	current int
+	// opened is true if the replica is opened from a data source name, replicas passed as *sql.DB are closed by their owners
+	opened bool
+}
+
+// replicaResolver picks replicas with smooth weighted round-robin
+type replicaResolver struct {
+	mutex    sync.Mutex
+	replicas []*replica
+}
+
+func openReplicas(driver string, replicas []Replica) (*replicaResolver, error) {
+	resolver := &replicaResolver{}
+	for _, r := range replicas {
+		var db sqlCommon
+		var opened bool
+		switch source := r.Source.(type) {
+		case string:
+			sqlDB, err := sql.Open(driver, source)
+			if err != nil {
+				resolver.close()
+				return nil, err
+			}
+			db, opened = sqlDB, true
+		case sqlCommon:
+			db = source
+		default:
+			resolver.close()
+			return nil, errors.New("invalid replica source")
+		}
+
+		weight := r.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		resolver.replicas = append(resolver.replicas, &replica{db: db, weight: weight, opened: opened})
+
+		if sqlDB, ok := db.(*sql.DB); ok {
+			if err := sqlDB.Ping(); err != nil {
+				resolver.close()
+				return nil, err
+			}
+		}
+	}
+	return resolver, nil
+}
+
+func (resolver *replicaResolver) next() sqlCommon {
+	resolver.mutex.Lock()
+	defer resolver.mutex.Unlock()
+
+	var total int
+	var selected *replica
+	for _, r := range resolver.replicas {
+		r.current += r.weight
+		total += r.weight
+		if selected == nil || r.current > selected.current {
+			selected = r
+		}
+	}
+	selected.current -= total
+	return selected.db
+}
+
+// close closes replicas opened from data source names
+func (resolver *replicaResolver) close() (err error) {
+	for _, r := range resolver.replicas {
+		if sqlDB, ok := r.db.(*sql.DB); ok && r.opened {
+			if e := sqlDB.Close(); e != nil {
+				err = e
+			}
+		}
+	}
+	return
+}
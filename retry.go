@@ -0,0 +1,56 @@
+package gorm
+
+import (
+	"errors"
+	"time"
+)
+
+// RetryPolicy decides how DB.WithRetry reruns transactions aborted by serialization failures or deadlocks, e.g:
+//
+//	db.WithRetry(gorm.RetryPolicy{MaxAttempts: 5, Backoff: 10 * time.Millisecond}, func(tx *gorm.DB) error {
+//		return tx.Model(&account).UpdateColumn("balance", gorm.Expr("balance - ?", amount)).Error
+//	})
+type RetryPolicy struct {
+	// MaxAttempts is the maximum times to run the transaction, including the first attempt, it will be run once if less than 1
+	MaxAttempts int
+	// Backoff is the delay before the second attempt, it's doubled for every following attempt
+	Backoff time.Duration
+	// MaxBackoff limits the delay between attempts, it's one minute if zero
+	MaxBackoff time.Duration
+	// Retryable decides which errors should be retried, ErrSerialization and ErrDeadlock are retried if it's nil
+	Retryable func(err error) bool
+}
+
+// ShouldRetry returns true if the transaction failed with err should be run again
+func (policy RetryPolicy) ShouldRetry(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if policy.Retryable != nil {
+		return policy.Retryable(err)
+	}
+	return errors.Is(err, ErrSerialization) || errors.Is(err, ErrDeadlock)
+}
+
+// defaultMaxBackoff limits the delay between attempts of policies without MaxBackoff
+const defaultMaxBackoff = time.Minute
+
+// Delay returns the delay before the attempt, the second attempt is delayed by Backoff
+func (policy RetryPolicy) Delay(attempt int) time.Duration {
+	maxBackoff := policy.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+
+	// stop doubling once reached the limit, so the delay doesn't overflow
+	delay := policy.Backoff
+	for i := 2; i < attempt && delay > 0 && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
@@ -1,6 +1,7 @@
 package gorm
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -70,6 +71,14 @@ func (scope *Scope) SqlDB() sqlCommon {
 	return scope.db.db
 }
 
+// Context return the context of current operation, set by DB.WithContext
+func (scope *Scope) Context() context.Context {
+	if scope.db == nil {
+		return context.Background()
+	}
+	return scope.db.Context()
+}
+
 // SkipLeft skip remaining callbacks
 func (scope *Scope) SkipLeft() {
 	scope.skipLeft = true
@@ -245,7 +254,9 @@ func (scope *Scope) CallMethodWithErrorCheck(name string) {
 
 // AddToVars add value as sql's vars, gorm will escape them
 func (scope *Scope) AddToVars(value interface{}) string {
-	if expr, ok := value.(*expr); ok {
+	if db, ok := value.(*DB); ok {
+		return "(" + scope.subQuerySql(db) + ")"
+	} else if expr, ok := value.(*expr); ok {
 		exp := expr.expr
 		for _, arg := range expr.args {
 			exp = strings.Replace(exp, "?", scope.AddToVars(arg), 1)
@@ -268,6 +279,9 @@ type dbTabler interface {
 // TableName get table name
 func (scope *Scope) TableName() string {
 	if scope.Search != nil && len(scope.Search.tableName) > 0 {
+		if len(scope.Search.tableArgs) > 0 {
+			return scope.Search.tableAlias()
+		}
 		return scope.Search.tableName
 	}
 
@@ -284,6 +298,9 @@ func (scope *Scope) TableName() string {
 
 func (scope *Scope) QuotedTableName() (name string) {
 	if scope.Search != nil && len(scope.Search.tableName) > 0 {
+		if len(scope.Search.tableArgs) > 0 {
+			return scope.Quote(scope.Search.tableAlias())
+		}
 		if strings.Index(scope.Search.tableName, " ") != -1 {
 			return scope.Search.tableName
 		}
@@ -316,16 +333,7 @@ func (scope *Scope) Raw(sql string) *Scope {
 
 // Exec invoke sql
 func (scope *Scope) Exec() *Scope {
-	defer scope.Trace(NowFunc())
-
-	if !scope.HasError() {
-		if result, err := scope.SqlDB().Exec(scope.Sql, scope.SqlVars...); scope.Err(err) == nil {
-			if count, err := result.RowsAffected(); scope.Err(err) == nil {
-				scope.db.RowsAffected = count
-			}
-		}
-	}
-	return scope
+	return scope.exec(OperationExec)
 }
 
 // Set set value by name
@@ -358,15 +366,19 @@ func (scope *Scope) InstanceGet(name string) (interface{}, bool) {
 // Trace print sql log
 func (scope *Scope) Trace(t time.Time) {
 	if len(scope.Sql) > 0 {
-		scope.db.slog(scope.Sql, t, scope.SqlVars...)
+		scope.db.slog(scope.Sql, t, scope.db.RowsAffected, scope.db.Error, scope.SqlVars...)
 	}
 }
 
 // Begin start a transaction
 func (scope *Scope) Begin() *Scope {
+	if scope.dryRun() {
+		return scope
+	}
+
 	if db, ok := scope.SqlDB().(sqlDb); ok {
-		if tx, err := db.Begin(); err == nil {
-			scope.db.db = interface{}(tx).(sqlCommon)
+		if tx, err := db.BeginTx(scope.Context(), nil); err == nil {
+			scope.db.db = scope.db.preparedTx(tx)
 			scope.InstanceSet("gorm:started_transaction", true)
 		}
 	}
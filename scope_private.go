@@ -1,13 +1,16 @@
 package gorm
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func (scope *Scope) primaryCondition(value interface{}) string {
@@ -38,6 +41,18 @@ func (scope *Scope) buildWhereCondition(clause map[string]interface{}) (str stri
 			}
 		}
 		return strings.Join(sqls, " AND ")
+	case *Condition:
+		return value.sql(scope)
+	case *JSONQueryExpression:
+		return value.sql(scope)
+	case *DB:
+		if value.search == nil {
+			return ""
+		}
+		if sql := scope.conditionSql(value.search); sql != "" {
+			return fmt.Sprintf("(%v)", sql)
+		}
+		return ""
 	case interface{}:
 		var sqls []string
 		for _, field := range scope.New(value).Fields() {
@@ -48,29 +63,112 @@ func (scope *Scope) buildWhereCondition(clause map[string]interface{}) (str stri
 		return strings.Join(sqls, " AND ")
 	}
 
-	args := clause["args"].([]interface{})
+	return scope.bindVars(str, clause["args"].([]interface{}))
+}
+
+// bindVars replaces `?` placeholders with args in order and `@name` placeholders with named args passed as sql.NamedArg
+// or map[string]interface{}, placeholders in quoted strings and unknown names are kept
+func (scope *Scope) bindVars(str string, args []interface{}) string {
+	var positional []interface{}
+	var named map[string]interface{}
 	for _, arg := range args {
-		switch reflect.ValueOf(arg).Kind() {
-		case reflect.Slice: // For where("id in (?)", []int64{1,2})
-			if bytes, ok := arg.([]byte); ok {
-				str = strings.Replace(str, "?", scope.AddToVars(bytes), 1)
-			} else {
-				values := reflect.ValueOf(arg)
-				var tempMarks []string
-				for i := 0; i < values.Len(); i++ {
-					tempMarks = append(tempMarks, scope.AddToVars(values.Index(i).Interface()))
-				}
-				str = strings.Replace(str, "?", strings.Join(tempMarks, ","), 1)
+		switch value := arg.(type) {
+		case sql.NamedArg:
+			if named == nil {
+				named = map[string]interface{}{}
+			}
+			named[value.Name] = value.Value
+		case map[string]interface{}:
+			if named == nil {
+				named = map[string]interface{}{}
+			}
+			for name, v := range value {
+				named[name] = v
 			}
 		default:
-			if valuer, ok := interface{}(arg).(driver.Valuer); ok {
-				arg, _ = valuer.Value()
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) == 0 && len(named) == 0 {
+		return str
+	}
+
+	var (
+		result strings.Builder
+		quote  byte
+	)
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			result.WriteByte(c)
+			continue
+		}
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			result.WriteByte(c)
+		case c == '?' && len(positional) > 0:
+			result.WriteString(scope.bindVar(positional[0], inParentheses(str, i, i+1)))
+			positional = positional[1:]
+		case c == '@' && len(named) > 0 && (i == 0 || !isNameByte(str[i-1]) && str[i-1] != '@'):
+			j := i + 1
+			for j < len(str) && isNameByte(str[j]) {
+				j++
 			}
 
-			str = strings.Replace(str, "?", scope.AddToVars(arg), 1)
+			if value, ok := named[str[i+1:j]]; ok && j > i+1 {
+				result.WriteString(scope.bindVar(value, inParentheses(str, i, j)))
+				i = j - 1
+			} else {
+				result.WriteByte(c)
+			}
+		default:
+			result.WriteByte(c)
 		}
 	}
-	return
+	return result.String()
+}
+
+// bindVar adds value to the scope's vars, slices are expanded for `IN (?)`, queries are written as parenthesized subqueries
+func (scope *Scope) bindVar(value interface{}, inParentheses bool) string {
+	if db, ok := value.(*DB); ok {
+		if inParentheses {
+			return scope.subQuerySql(db)
+		}
+		return "(" + scope.subQuerySql(db) + ")"
+	}
+
+	if _, ok := value.([]byte); !ok && reflect.ValueOf(value).Kind() == reflect.Slice {
+		values := reflect.ValueOf(value)
+		var tempMarks []string
+		for i := 0; i < values.Len(); i++ {
+			tempMarks = append(tempMarks, scope.AddToVars(values.Index(i).Interface()))
+		}
+		return strings.Join(tempMarks, ",")
+	}
+
+	if valuer, ok := value.(driver.Valuer); ok {
+		var err error
+		value, err = valuer.Value()
+		scope.Err(err)
+	}
+	return scope.AddToVars(value)
+}
+
+// inParentheses reports whether the placeholder str[start:end] is enclosed in parentheses, like `IN (?)`
+func inParentheses(str string, start, end int) bool {
+	before := strings.TrimRight(str[:start], " \t\n")
+	after := strings.TrimLeft(str[end:], " \t\n")
+	return strings.HasSuffix(before, "(") && strings.HasPrefix(after, ")")
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
 }
 
 func (scope *Scope) buildNotCondition(clause map[string]interface{}) (str string) {
@@ -108,6 +206,18 @@ func (scope *Scope) buildNotCondition(clause map[string]interface{}) (str string
 			}
 		}
 		return strings.Join(sqls, " AND ")
+	case *Condition:
+		return fmt.Sprintf("(NOT %v)", value.sql(scope))
+	case *JSONQueryExpression:
+		return fmt.Sprintf("(NOT %v)", value.sql(scope))
+	case *DB:
+		if value.search == nil {
+			return ""
+		}
+		if sql := scope.conditionSql(value.search); sql != "" {
+			return fmt.Sprintf("(NOT (%v))", sql)
+		}
+		return ""
 	case interface{}:
 		var sqls []string
 		for _, field := range scope.New(value).Fields() {
@@ -119,23 +229,10 @@ func (scope *Scope) buildNotCondition(clause map[string]interface{}) (str string
 	}
 
 	args := clause["args"].([]interface{})
-	for _, arg := range args {
-		switch reflect.ValueOf(arg).Kind() {
-		case reflect.Slice: // For where("id in (?)", []int64{1,2})
-			values := reflect.ValueOf(arg)
-			var tempMarks []string
-			for i := 0; i < values.Len(); i++ {
-				tempMarks = append(tempMarks, scope.AddToVars(values.Index(i).Interface()))
-			}
-			str = strings.Replace(str, "?", strings.Join(tempMarks, ","), 1)
-		default:
-			if scanner, ok := interface{}(arg).(driver.Valuer); ok {
-				arg, _ = scanner.Value()
-			}
-			str = strings.Replace(notEqualSql, "?", scope.AddToVars(arg), 1)
-		}
+	if len(args) > 0 && notEqualSql != "" && reflect.ValueOf(args[len(args)-1]).Kind() != reflect.Slice {
+		str = notEqualSql
 	}
-	return
+	return scope.bindVars(str, args)
 }
 
 func (scope *Scope) buildSelectQuery(clause map[string]interface{}) (str string) {
@@ -146,32 +243,14 @@ func (scope *Scope) buildSelectQuery(clause map[string]interface{}) (str string)
 		str = strings.Join(value, ", ")
 	}
 
-	args := clause["args"].([]interface{})
-	for _, arg := range args {
-		switch reflect.ValueOf(arg).Kind() {
-		case reflect.Slice:
-			values := reflect.ValueOf(arg)
-			var tempMarks []string
-			for i := 0; i < values.Len(); i++ {
-				tempMarks = append(tempMarks, scope.AddToVars(values.Index(i).Interface()))
-			}
-			str = strings.Replace(str, "?", strings.Join(tempMarks, ","), 1)
-		default:
-			if valuer, ok := interface{}(arg).(driver.Valuer); ok {
-				arg, _ = valuer.Value()
-			}
-			str = strings.Replace(str, "?", scope.AddToVars(arg), 1)
-		}
-	}
-	return
+	return scope.bindVars(str, clause["args"].([]interface{}))
 }
 
 func (scope *Scope) whereSql() (sql string) {
-	var primaryConditions, andConditions, orConditions []string
+	var primaryConditions []string
 
-	if !scope.Search.Unscoped && scope.Fields()["deleted_at"] != nil {
-		sql := fmt.Sprintf("(%v.deleted_at IS NULL OR %v.deleted_at <= '0001-01-02')", scope.QuotedTableName(), scope.QuotedTableName())
-		primaryConditions = append(primaryConditions, sql)
+	if field := scope.softDeleteField(); !scope.Search.Unscoped && field != nil {
+		primaryConditions = append(primaryConditions, scope.softDeleteCondition(field, scope.QuotedTableName()))
 	}
 
 	if !scope.PrimaryKeyZero() {
@@ -179,43 +258,59 @@ func (scope *Scope) whereSql() (sql string) {
 			sql := fmt.Sprintf("(%v = %v)", scope.Quote(field.DBName), scope.AddToVars(field.Field.Interface()))
 			primaryConditions = append(primaryConditions, sql)
 		}
+
+		// the version checked by Update is ANDed like primary keys, so it couldn't be bypassed by Or conditions
+		if value, ok := scope.InstanceGet("gorm:version_condition"); ok {
+			if field, ok := value.(*Field); ok {
+				sql := fmt.Sprintf("(%v.%v = %v)", scope.QuotedTableName(), scope.Quote(field.DBName), scope.AddToVars(field.Field.Interface()))
+				primaryConditions = append(primaryConditions, sql)
+			}
+		}
 	}
 
-	for _, clause := range scope.Search.whereConditions {
-		if sql := scope.buildWhereCondition(clause); sql != "" {
-			andConditions = append(andConditions, sql)
+	combinedSql := scope.conditionSql(scope.Search)
+
+	if len(primaryConditions) > 0 {
+		sql = "WHERE " + strings.Join(primaryConditions, " AND ")
+		if len(combinedSql) > 0 {
+			sql = sql + " AND (" + combinedSql + ")"
 		}
+	} else if len(combinedSql) > 0 {
+		sql = "WHERE " + combinedSql
 	}
+	return
+}
+
+// conditionSql combines the where, or and not conditions of search
+func (scope *Scope) conditionSql(search *search) (sql string) {
+	var andConditions, orConditions []string
 
-	for _, clause := range scope.Search.orConditions {
+	for _, clause := range search.whereConditions {
 		if sql := scope.buildWhereCondition(clause); sql != "" {
-			orConditions = append(orConditions, sql)
+			andConditions = append(andConditions, sql)
 		}
 	}
 
-	for _, clause := range scope.Search.notConditions {
+	for _, clause := range search.notConditions {
 		if sql := scope.buildNotCondition(clause); sql != "" {
 			andConditions = append(andConditions, sql)
 		}
 	}
 
-	orSql := strings.Join(orConditions, " OR ")
-	combinedSql := strings.Join(andConditions, " AND ")
-	if len(combinedSql) > 0 {
-		if len(orSql) > 0 {
-			combinedSql = combinedSql + " OR " + orSql
+	for _, clause := range search.orConditions {
+		if sql := scope.buildWhereCondition(clause); sql != "" {
+			orConditions = append(orConditions, sql)
 		}
-	} else {
-		combinedSql = orSql
 	}
 
-	if len(primaryConditions) > 0 {
-		sql = "WHERE " + strings.Join(primaryConditions, " AND ")
-		if len(combinedSql) > 0 {
-			sql = sql + " AND (" + combinedSql + ")"
+	orSql := strings.Join(orConditions, " OR ")
+	sql = strings.Join(andConditions, " AND ")
+	if len(sql) > 0 {
+		if len(orSql) > 0 {
+			sql = sql + " OR " + orSql
 		}
-	} else if len(combinedSql) > 0 {
-		sql = "WHERE " + combinedSql
+	} else {
+		sql = orSql
 	}
 	return
 }
@@ -224,16 +319,52 @@ var hasCountRegexp = regexp.MustCompile(`(?i)count\(.+\)`)
 
 func (scope *Scope) selectSql() string {
 	if len(scope.Search.selects) == 0 {
-		if scope.Search.joins != "" {
-			return fmt.Sprintf("%v.*", scope.QuotedTableName())
+		columns := scope.readableColumns()
+		if len(scope.Search.joins) > 0 {
+			if columns == nil {
+				columns = []string{fmt.Sprintf("%v.*", scope.QuotedTableName())}
+			}
+			for _, clause := range scope.Search.joins {
+				if field, ok := scope.joinedAssociation(clause["query"].(string)); ok {
+					for _, associationField := range scope.New(reflect.New(field.Struct.Type).Interface()).GetStructFields() {
+						if associationField.IsNormal && !associationField.IsIgnored && associationField.IsReadable {
+							columns = append(columns, fmt.Sprintf("%v.%v AS %v", scope.Quote(field.Name), scope.Quote(associationField.DBName), scope.Quote(field.Name+"__"+associationField.DBName)))
+						}
+					}
+				}
+			}
+		}
+
+		if columns == nil {
+			return "*"
 		}
-		return "*"
+		return strings.Join(columns, ", ")
 	}
 	sql := scope.buildSelectQuery(scope.Search.selects)
 	scope.Search.countingQuery = (len(scope.Search.group) == 0) && hasCountRegexp.MatchString(sql)
 	return sql
 }
 
+// readableColumns returns the columns of model's readable fields if some fields are excluded from selects with `->:false`,
+// otherwise returns nil to select all columns
+func (scope *Scope) readableColumns() (columns []string) {
+	var hasUnreadable bool
+	for _, field := range scope.GetStructFields() {
+		if field.IsNormal && !field.IsIgnored {
+			if field.IsReadable {
+				columns = append(columns, fmt.Sprintf("%v.%v", scope.QuotedTableName(), scope.Quote(field.DBName)))
+			} else {
+				hasUnreadable = true
+			}
+		}
+	}
+
+	if !hasUnreadable {
+		return nil
+	}
+	return
+}
+
 func (scope *Scope) orderSql() string {
 	if len(scope.Search.orders) == 0 || scope.Search.countingQuery {
 		return ""
@@ -307,18 +438,321 @@ func (scope *Scope) havingSql() string {
 }
 
 func (scope *Scope) joinsSql() string {
-	return scope.Search.joins + " "
+	var sqls []string
+	for _, clause := range scope.Search.joins {
+		query := clause["query"].(string)
+		if field, ok := scope.joinedAssociation(query); ok {
+			sqls = append(sqls, scope.associationJoinSql(field))
+		} else {
+			sqls = append(sqls, scope.bindVars(query, clause["args"].([]interface{})))
+		}
+	}
+	return strings.Join(sqls, " ") + " "
+}
+
+// joinedAssociation returns the belongs to or has one association named name
+func (scope *Scope) joinedAssociation(name string) (*StructField, bool) {
+	for _, field := range scope.GetStructFields() {
+		if field.Name == name && field.Relationship != nil && (field.Relationship.Kind == "belongs_to" || field.Relationship.Kind == "has_one") {
+			return field, true
+		}
+	}
+	return nil, false
+}
+
+// associationJoinSql left joins the association's table aliased with the association's name
+func (scope *Scope) associationJoinSql(field *StructField) string {
+	var (
+		relationship    = field.Relationship
+		toScope         = scope.New(reflect.New(field.Struct.Type).Interface())
+		alias           = scope.Quote(field.Name)
+		quotedTableName = scope.QuotedTableName()
+		conditions      []string
+	)
+
+	for idx, foreignDBName := range relationship.ForeignDBNames {
+		if relationship.Kind == "belongs_to" {
+			conditions = append(conditions, fmt.Sprintf("%v.%v = %v.%v", alias, scope.Quote(relationship.AssociationForeignDBNames[idx]), quotedTableName, scope.Quote(foreignDBName)))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("%v.%v = %v.%v", alias, scope.Quote(foreignDBName), quotedTableName, scope.Quote(relationship.AssociationForeignDBNames[idx])))
+		}
+	}
+
+	if relationship.PolymorphicDBName != "" {
+		conditions = append(conditions, fmt.Sprintf("%v.%v = %v", alias, scope.Quote(relationship.PolymorphicDBName), scope.AddToVars(scope.TableName())))
+	}
+
+	if field := toScope.softDeleteField(); !scope.Search.Unscoped && field != nil {
+		conditions = append(conditions, scope.softDeleteCondition(field, alias))
+	}
+
+	return fmt.Sprintf("LEFT JOIN %v %v ON %v", toScope.QuotedTableName(), alias, strings.Join(conditions, " AND "))
+}
+
+// scanRow scans the current row of rows into elem, which could be a map[string]interface{} to get all columns,
+// a struct to map columns to its fields by their names, and to fields of nested structs by aliases like `Company__name`,
+// or any other value, like a string or a time.Time, to get the first column
+func (scope *Scope) scanRow(rows *sql.Rows, columns []string, elem reflect.Value) error {
+	if elem.Kind() == reflect.Map {
+		return scanMap(rows, columns, elem)
+	}
+
+	// structs could be scanners by embedding one, they are only scanned as a value for a single column that isn't their field
+	if _, isScanner := elem.Addr().Interface().(sql.Scanner); elem.Kind() != reflect.Struct || elem.Type() == reflect.TypeOf(time.Time{}) ||
+		isScanner && len(columns) == 1 && scope.New(elem.Addr().Interface()).Fields()[columns[0]] == nil {
+		return scanValue(rows, columns, elem)
+	}
+
+	var (
+		values       = make([]interface{}, len(columns))
+		columnFields = make([]*Field, len(columns))
+		associations = make([]*Field, len(columns))
+		joined       = map[string]map[string]*Field{}
+		fields       = scope.New(elem.Addr().Interface()).Fields()
+	)
+
+	for index, column := range columns {
+		field, ok := fields[column]
+		if !ok {
+			associations[index], field, ok = scope.joinedField(fields, column, joined)
+		}
+
+		if ok && field.IsReadable {
+			columnFields[index] = field
+			if field.Field.Kind() == reflect.Ptr {
+				values[index] = field.Field.Addr().Interface()
+			} else {
+				reflectValue := reflect.New(reflect.PtrTo(field.Struct.Type))
+				reflectValue.Elem().Set(field.Field.Addr())
+				values[index] = reflectValue.Interface()
+			}
+		} else {
+			var value interface{}
+			values[index] = &value
+		}
+	}
+
+	if err := rows.Scan(values...); err != nil {
+		return err
+	}
+
+	// pointers to joined associations without any value are set back to nil
+	var found = map[*Field]bool{}
+	for index := range columns {
+		value := values[index]
+		if field := columnFields[index]; field != nil {
+			isNull := true
+			if field.Field.Kind() == reflect.Ptr {
+				field.Field.Set(reflect.ValueOf(value).Elem())
+				isNull = field.Field.IsNil()
+			} else if v := reflect.ValueOf(value).Elem().Elem(); v.IsValid() {
+				field.Field.Set(v)
+				isNull = false
+			}
+
+			if association := associations[index]; association != nil && !isNull {
+				found[association] = true
+			}
+		}
+	}
+
+	for _, association := range associations {
+		if association != nil && !found[association] && association.Field.Kind() == reflect.Ptr {
+			association.Field.Set(reflect.Zero(association.Field.Type()))
+		}
+	}
+	return nil
+}
+
+// scanMap scans the current row of rows into elem, a map[string]interface{}, text columns returned as bytes are converted to strings
+func scanMap(rows *sql.Rows, columns []string, elem reflect.Value) error {
+	if elem.Type() != reflect.TypeOf(map[string]interface{}{}) {
+		return errors.New("unsupported destination, map should be map[string]interface{}")
+	}
+
+	values := make([]interface{}, len(columns))
+	for index := range values {
+		values[index] = new(interface{})
+	}
+	if err := rows.Scan(values...); err != nil {
+		return err
+	}
+
+	columnTypes, _ := rows.ColumnTypes()
+	if elem.IsNil() {
+		elem.Set(reflect.MakeMap(elem.Type()))
+	}
+
+	for index, column := range columns {
+		value := *values[index].(*interface{})
+		if bytes, ok := value.([]byte); ok {
+			if len(columnTypes) > index && columnTypes[index].ScanType() == reflect.TypeOf([]byte{}) {
+				value = append([]byte{}, bytes...)
+			} else {
+				value = string(bytes)
+			}
+		}
+
+		if value == nil {
+			elem.SetMapIndex(reflect.ValueOf(column), reflect.Zero(elem.Type().Elem()))
+		} else {
+			elem.SetMapIndex(reflect.ValueOf(column), reflect.ValueOf(value))
+		}
+	}
+	return nil
+}
+
+// scanValue scans the first column of the current row of rows into elem, NULL leaves elem unchanged unless it's a pointer
+func scanValue(rows *sql.Rows, columns []string, elem reflect.Value) error {
+	values := make([]interface{}, len(columns))
+	for index := range values {
+		values[index] = new(interface{})
+	}
+
+	if len(values) > 0 {
+		if elem.Kind() == reflect.Ptr {
+			values[0] = elem.Addr().Interface()
+		} else {
+			values[0] = reflect.New(reflect.PtrTo(elem.Type())).Interface()
+		}
+	}
+
+	if err := rows.Scan(values...); err != nil {
+		return err
+	}
+
+	if len(values) > 0 && elem.Kind() != reflect.Ptr {
+		if value := reflect.ValueOf(values[0]).Elem().Elem(); value.IsValid() {
+			elem.Set(value)
+		}
+	}
+	return nil
+}
+
+// joinedField returns the nested struct field and its field for a column aliased like `Company__name` or `company__owner__name`,
+// which could be a joined association or any nested struct, nested pointers are allocated, fields of nested structs are cached in joined
+func (scope *Scope) joinedField(fields map[string]*Field, column string, joined map[string]map[string]*Field) (association *Field, field *Field, ok bool) {
+	var path string
+	for {
+		if association != nil {
+			if field, ok = fields[column]; ok {
+				return association, field, true
+			}
+		}
+
+		idx := strings.Index(column, "__")
+		if idx <= 0 {
+			return nil, nil, false
+		}
+
+		var name, nested = column[:idx], (*Field)(nil)
+		for _, f := range fields {
+			if (f.Name == name || f.DBName == name) && !f.IsNormal && f.Field.IsValid() && indirectType(f.Struct.Type).Kind() == reflect.Struct {
+				nested = f
+				break
+			}
+		}
+		if nested == nil {
+			return nil, nil, false
+		}
+
+		if association == nil {
+			association = nested
+		}
+		column = column[idx+2:]
+		path += nested.Name + "__"
+
+		nestedFields, found := joined[path]
+		if !found {
+			value := nested.Field
+			if value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					value.Set(reflect.New(value.Type().Elem()))
+				}
+				value = value.Elem()
+			}
+			nestedFields = scope.New(value.Addr().Interface()).Fields()
+			joined[path] = nestedFields
+		}
+		fields = nestedFields
+	}
 }
 
 func (scope *Scope) prepareQuerySql() {
 	if scope.Search.raw {
 		scope.Raw(strings.TrimSuffix(strings.TrimPrefix(scope.CombinedConditionSql(), " WHERE ("), ")"))
 	} else {
-		scope.Raw(fmt.Sprintf("SELECT %v %v FROM %v %v", scope.topSql(), scope.selectSql(), scope.QuotedTableName(), scope.CombinedConditionSql()))
+		scope.Raw(fmt.Sprintf("SELECT %v %v FROM %v %v", scope.topSql(), scope.selectSql(), scope.fromSql(), scope.CombinedConditionSql()))
 	}
 	return
 }
 
+// versionField returns the integer field tagged with `gorm:"version"` used for optimistic locking
+func (scope *Scope) versionField() *Field {
+	for _, field := range scope.Fields() {
+		if _, ok := field.TagSettings["VERSION"]; ok && field.IsNormal && field.Field.IsValid() {
+			return field
+		}
+	}
+	return nil
+}
+
+// setAutoTime sets fields tagged with setting, `AUTOCREATETIME` or `AUTOUPDATETIME`, to now, integer fields are set to unix seconds,
+// or milliseconds, nanoseconds with `autoCreateTime:milli`, `autoUpdateTime:nano`. The updating attrs are also set, so the fields
+// are updated by batch updates
+func (scope *Scope) setAutoTime(setting string, now time.Time) {
+	for _, field := range scope.Fields() {
+		if _, ok := field.TagSettings[setting]; !ok || !field.IsNormal {
+			continue
+		}
+
+		var value interface{} = now
+		switch indirectType(field.Struct.Type).Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			switch strings.ToUpper(field.TagSettings[setting]) {
+			case "NANO":
+				value = now.UnixNano()
+			case "MILLI":
+				value = now.UnixNano() / int64(time.Millisecond)
+			default:
+				value = now.Unix()
+			}
+		}
+
+		scope.SetColumn(field, value)
+		if updateAttrs, ok := scope.InstanceGet("gorm:update_attrs"); ok {
+			updateAttrs.(map[string]interface{})[field.DBName] = value
+		}
+	}
+}
+
+func (scope *Scope) increaseVersion(field *Field) {
+	value := reflect.Indirect(field.Field)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value.SetInt(value.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value.SetUint(value.Uint() + 1)
+	}
+}
+
+// fromSql returns the quoted table name, or the table expression with its args bound
+func (scope *Scope) fromSql() string {
+	if len(scope.Search.tableArgs) > 0 {
+		return scope.bindVars(scope.Search.tableName, scope.Search.tableArgs)
+	}
+	return scope.QuotedTableName()
+}
+
+// subQuerySql builds the query of db, its vars are appended to the scope's vars so placeholders keep being numbered in order
+func (scope *Scope) subQuerySql(db *DB) string {
+	subScope := db.NewScope(db.Value)
+	subScope.SqlVars = scope.SqlVars
+	subScope.prepareQuerySql()
+	scope.SqlVars = subScope.SqlVars
+	return strings.TrimSpace(subScope.Sql)
+}
+
 func (scope *Scope) inlineCondition(values ...interface{}) *Scope {
 	if len(values) > 0 {
 		scope.Search.Where(values[0], values[1:]...)
@@ -326,6 +760,22 @@ func (scope *Scope) inlineCondition(values ...interface{}) *Scope {
 	return scope
 }
 
+// forEachElement calls fc with every element's scope if scope's value is a slice, otherwise with scope itself,
+// element scopes share scope's DB, so their errors and transaction are scope's
+func (scope *Scope) forEachElement(fc func(*Scope)) {
+	if values := scope.IndirectValue(); values.Kind() == reflect.Slice {
+		for i := 0; i < values.Len(); i++ {
+			value := values.Index(i)
+			if value.Kind() != reflect.Ptr {
+				value = value.Addr()
+			}
+			fc(&Scope{db: scope.db, Search: scope.Search, Value: value.Interface()})
+		}
+	} else {
+		fc(scope)
+	}
+}
+
 func (scope *Scope) callCallbacks(funcs []*func(s *Scope)) *Scope {
 	for _, f := range funcs {
 		(*f)(scope)
@@ -358,7 +808,7 @@ func (scope *Scope) updatedAttrsWithValues(values map[string]interface{}, ignore
 	if hasExpr {
 		var updateMap = map[string]interface{}{}
 		for key, field := range scope.Fields() {
-			if field.IsNormal {
+			if field.IsNormal && field.IsUpdatable {
 				if v, ok := values[key]; ok {
 					updateMap[key] = v
 				} else {
@@ -371,18 +821,108 @@ func (scope *Scope) updatedAttrsWithValues(values map[string]interface{}, ignore
 	return
 }
 
-func (scope *Scope) row() *sql.Row {
+func (scope *Scope) row() (row *sql.Row) {
 	defer scope.Trace(NowFunc())
 	scope.callCallbacks(scope.db.parent.callback.rowQueries)
 	scope.prepareQuerySql()
-	return scope.SqlDB().QueryRow(scope.Sql, scope.SqlVars...)
+	if scope.dryRun() {
+		scope.instrument(OperationRowQuery, nil)
+		return dryRunDB.QueryRow(scope.Sql)
+	}
+	scope.instrument(OperationRowQuery, func(ctx context.Context) error {
+		row = scope.readDB().QueryRowContext(ctx, scope.Sql, scope.SqlVars...)
+		return row.Err()
+	})
+	return
+}
+
+func (scope *Scope) rows() (rows *sql.Rows, err error) {
+	defer scope.Trace(NowFunc())
+	scope.callCallbacks(scope.db.parent.callback.rowQueries)
+	scope.prepareQuerySql()
+	if scope.dryRun() {
+		scope.instrument(OperationRowQuery, nil)
+		return dryRunDB.Query(scope.Sql)
+	}
+	scope.instrument(OperationRowQuery, func(ctx context.Context) error {
+		rows, err = scope.readDB().QueryContext(ctx, scope.Sql, scope.SqlVars...)
+		return err
+	})
+	return
 }
 
-func (scope *Scope) rows() (*sql.Rows, error) {
+// buildRowQuery builds the row query and records it as the statement without running it, for dry run mode
+func (scope *Scope) buildRowQuery() {
 	defer scope.Trace(NowFunc())
 	scope.callCallbacks(scope.db.parent.callback.rowQueries)
 	scope.prepareQuerySql()
-	return scope.SqlDB().Query(scope.Sql, scope.SqlVars...)
+	scope.instrument(OperationRowQuery, nil)
+}
+
+// readDB returns the database to run queries, which is a replica if there are any, unless in a transaction or using primary
+func (scope *Scope) readDB() sqlCommon {
+	if resolver := scope.db.parent.replicas; resolver != nil {
+		if _, ok := scope.SqlDB().(sqlTx); !ok {
+			if value, ok := scope.Get("gorm:use_primary"); !ok || value != true {
+				return scope.db.preparedDB(resolver.next())
+			}
+		}
+	}
+	return scope.writeDB()
+}
+
+// dryRun returns true if statements should be built without executing, set by DB.DryRun
+func (scope *Scope) dryRun() bool {
+	value, ok := scope.Get("gorm:dry_run")
+	return ok && value == true
+}
+
+// writeDB returns the database to run statements that change data
+func (scope *Scope) writeDB() sqlCommon {
+	return scope.db.preparedDB(scope.SqlDB())
+}
+
+// exec execute the sql with operation type for instrumenter
+func (scope *Scope) exec(operation string) *Scope {
+	defer scope.Trace(NowFunc())
+
+	if !scope.HasError() {
+		scope.instrument(operation, func(ctx context.Context) error {
+			result, err := scope.writeDB().ExecContext(ctx, scope.Sql, scope.SqlVars...)
+			if scope.Err(err) == nil {
+				if count, err := result.RowsAffected(); scope.Err(err) == nil {
+					scope.db.RowsAffected = count
+				}
+			}
+			return err
+		})
+	}
+	return scope
+}
+
+// instrument run the statement with fc, and notify the DB's instrumenter before and after it, the statement is only recorded in dry run mode
+func (scope *Scope) instrument(operation string, fc func(ctx context.Context) error) {
+	if scope.dryRun() {
+		scope.db.statementSql, scope.db.statementVars = scope.Sql, scope.SqlVars
+		return
+	}
+
+	scope.db.tracingStatement = true
+	defer func() { scope.db.tracingStatement = false }()
+
+	instrumenter := scope.db.instrumenter
+	if instrumenter == nil {
+		fc(scope.Context())
+		return
+	}
+
+	event := &StatementEvent{Operation: operation, Table: scope.TableName(), Sql: scope.Sql, Vars: scope.SqlVars, StartTime: NowFunc()}
+	ctx := instrumenter.StatementStart(scope.Context(), event)
+	err := fc(ctx)
+	event.Duration = NowFunc().Sub(event.StartTime)
+	event.RowsAffected = scope.db.RowsAffected
+	event.Error = err
+	instrumenter.StatementEnd(ctx, event)
 }
 
 func (scope *Scope) initialize() *Scope {
@@ -402,6 +942,11 @@ func (scope *Scope) pluck(column string, value interface{}) *Scope {
 		return scope
 	}
 
+	if scope.dryRun() {
+		scope.buildRowQuery()
+		return scope
+	}
+
 	rows, err := scope.rows()
 	if scope.Err(err) == nil {
 		defer rows.Close()
@@ -416,6 +961,10 @@ func (scope *Scope) pluck(column string, value interface{}) *Scope {
 
 func (scope *Scope) count(value interface{}) *Scope {
 	scope.Search.Select("count(*)")
+	if scope.dryRun() {
+		scope.buildRowQuery()
+		return scope
+	}
 	scope.Err(scope.row().Scan(value))
 	return scope
 }
@@ -1,6 +1,9 @@
 package gorm
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type search struct {
 	db               *DB
@@ -13,12 +16,13 @@ type search struct {
 	selects          map[string]interface{}
 	omits            []string
 	orders           []string
-	joins            string
+	joins            []map[string]interface{}
 	preload          []searchPreload
 	offset           string
 	limit            string
 	group            string
 	tableName        string
+	tableArgs        []interface{}
 	raw              bool
 	Unscoped         bool
 	countingQuery    bool
@@ -102,8 +106,8 @@ func (s *search) Having(query string, values ...interface{}) *search {
 	return s
 }
 
-func (s *search) Joins(query string) *search {
-	s.joins = query
+func (s *search) Joins(query string, values ...interface{}) *search {
+	s.joins = append(s.joins, map[string]interface{}{"query": query, "args": values})
 	return s
 }
 
@@ -129,11 +133,18 @@ func (s *search) unscoped() *search {
 	return s
 }
 
-func (s *search) Table(name string) *search {
+func (s *search) Table(name string, args ...interface{}) *search {
 	s.tableName = name
+	s.tableArgs = args
 	return s
 }
 
+// tableAlias returns the alias of a table expression with args, e.g. `u` for `(?) AS u`
+func (s *search) tableAlias() string {
+	fields := strings.Fields(s.tableName)
+	return fields[len(fields)-1]
+}
+
 func (s *search) getInterfaceAsSql(value interface{}) (str string) {
 	switch value.(type) {
 	case string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
@@ -0,0 +1,106 @@
+package gorm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DeletedAt is a nullable time to mark records as soft deleted, models with a DeletedAt field are soft deleted by Delete,
+// and deleted records are excluded from queries, preloads, associations and joins unless Unscoped
+//
+//	type User struct {
+//		ID        int
+//		Name      string
+//		DeletedAt gorm.DeletedAt `sql:"index"`
+//	}
+//
+// Other fields could be used for soft delete with tag `soft_delete`, it stores the deleted time for time fields,
+// unix seconds for integer fields, and `true` for bool fields, or set the representation explicitly
+//
+//	Removed   int64 `gorm:"soft_delete:unix"`
+//	IsDeleted int   `gorm:"soft_delete:flag"`
+type DeletedAt struct {
+	Time  time.Time
+	Valid bool
+}
+
+func (n *DeletedAt) Scan(value interface{}) error {
+	var nullTime sql.NullTime
+	err := nullTime.Scan(value)
+	n.Time, n.Valid = nullTime.Time, nullTime.Valid
+	return err
+}
+
+func (n DeletedAt) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Time, nil
+}
+
+const (
+	softDeleteTime = "TIME"
+	softDeleteUnix = "UNIX"
+	softDeleteFlag = "FLAG"
+)
+
+// softDeleteField returns the field used to soft delete records: a DeletedAt field, a field tagged with `soft_delete`, or a `Deleted_At` time field
+func (scope *Scope) softDeleteField() *StructField {
+	for _, field := range scope.GetStructFields() {
+		if field.IsIgnored || !field.IsNormal {
+			continue
+		}
+
+		if _, ok := field.TagSettings["SOFT_DELETE"]; ok {
+			return field
+		}
+
+		if fieldType := indirectType(field.Struct.Type); fieldType == reflect.TypeOf(DeletedAt{}) {
+			return field
+		} else if (field.Name == "Deleted_At" || field.Name == "DeletedAt") && fieldType == reflect.TypeOf(time.Time{}) {
+			return field
+		}
+	}
+	return nil
+}
+
+func softDeleteMode(field *StructField) string {
+	if mode := strings.ToUpper(strings.TrimSpace(field.TagSettings["SOFT_DELETE"])); mode == softDeleteUnix || mode == softDeleteFlag || mode == softDeleteTime {
+		return mode
+	}
+
+	switch indirectType(field.Struct.Type).Kind() {
+	case reflect.Bool:
+		return softDeleteFlag
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return softDeleteUnix
+	}
+	return softDeleteTime
+}
+
+// softDeletedValue returns the value stored to field when a record is soft deleted
+func softDeletedValue(field *StructField) interface{} {
+	switch softDeleteMode(field) {
+	case softDeleteUnix:
+		return NowFunc().Unix()
+	case softDeleteFlag:
+		if indirectType(field.Struct.Type).Kind() == reflect.Bool {
+			return true
+		}
+		return 1
+	}
+	return NowFunc()
+}
+
+// softDeleteCondition returns the condition excluding records soft deleted with field from table, which is a quoted table name or alias
+func (scope *Scope) softDeleteCondition(field *StructField, table string) string {
+	column := fmt.Sprintf("%v.%v", table, scope.Quote(field.DBName))
+	if softDeleteMode(field) == softDeleteTime {
+		return fmt.Sprintf("(%v IS NULL OR %v <= '0001-01-02')", column, column)
+	}
+	return fmt.Sprintf("(%v IS NULL OR %v = %v)", column, column, scope.AddToVars(reflect.Zero(indirectType(field.Struct.Type)).Interface()))
+}
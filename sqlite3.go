@@ -10,7 +10,15 @@ type sqlite3 struct {
 	commonDialect
 }
 
+func (sqlite3) SupportRowValueComparison() bool {
+	return true
+}
+
 func (sqlite3) SqlTag(value reflect.Value, size int, autoIncrease bool) string {
+	if isJSON(value) {
+		return "text"
+	}
+
 	switch value.Kind() {
 	case reflect.Bool:
 		return "bool"
@@ -43,6 +51,16 @@ func (sqlite3) SqlTag(value reflect.Value, size int, autoIncrease bool) string {
 	panic(fmt.Sprintf("invalid sql type %s (%s) for sqlite3", value.Type().Name(), value.Kind().String()))
 }
 
+// FirstInsertId sqlite reports the id of the last row inserted by a multi-row insert as the last insert id
+func (sqlite3) FirstInsertId(lastInsertId int64, rows int64) int64 {
+	return lastInsertId - rows + 1
+}
+
+// SupportConsecutiveInsertIds sqlite inserts rows of a statement with increasing rowids as there is only one writer
+func (sqlite3) SupportConsecutiveInsertIds() bool {
+	return true
+}
+
 func (s sqlite3) HasTable(scope *Scope, tableName string) bool {
 	var count int
 	s.RawScanInt(scope, &count, "SELECT count(*) FROM sqlite_master WHERE type='table' AND name=?", tableName)
@@ -74,7 +92,7 @@ func (sqlite3) CurrentDatabase(scope *Scope) (name string) {
 	for i = 0; i < 3; i++ {
 		ifaces[i] = &pointers[i]
 	}
-	if err := scope.NewDB().Raw("PRAGMA database_list").Row().Scan(ifaces...); scope.Err(err) != nil {
+	if err := inspectDB(scope).Raw("PRAGMA database_list").Row().Scan(ifaces...); scope.Err(err) != nil {
 		return
 	}
 	if pointers[1] != nil {
@@ -82,3 +100,36 @@ func (sqlite3) CurrentDatabase(scope *Scope) (name string) {
 	}
 	return
 }
+
+func (sqlite3) TranslateError(err error) error {
+	// the ExtendedCode and Code of github.com/mattn/go-sqlite3's Error
+	if code, ok := errorNumber(err, "ExtendedCode"); ok {
+		switch code {
+		case 1555, 2067: // SQLITE_CONSTRAINT_PRIMARYKEY, SQLITE_CONSTRAINT_UNIQUE
+			return translateError(ErrDuplicatedKey, err)
+		case 787: // SQLITE_CONSTRAINT_FOREIGNKEY
+			return translateError(ErrForeignKeyViolated, err)
+		case 275: // SQLITE_CONSTRAINT_CHECK
+			return translateError(ErrCheckConstraint, err)
+		case 517: // SQLITE_BUSY_SNAPSHOT, the snapshot of a read transaction is stale when it tries to write
+			return translateError(ErrSerialization, err)
+		}
+	}
+
+	if code, ok := errorNumber(err, "Code"); ok && (code == 5 || code == 6) {
+		// SQLITE_BUSY and SQLITE_LOCKED, the database or table is locked by another connection
+		return translateError(ErrLocked, err)
+	}
+	return err
+}
+
+var sqlite3LiteralFormat = literalFormat{
+	timeLayout: "2006-01-02 15:04:05.999999999-07:00",
+	trueValue:  "1",
+	falseValue: "0",
+	bytes:      defaultLiteralFormat.bytes,
+}
+
+func (sqlite3) LiteralValue(value interface{}) string {
+	return formatLiteral(value, sqlite3LiteralFormat)
+}
@@ -167,6 +167,13 @@ type Comment struct {
 	Post    Post
 }
 
+// Optimistic locking
+type Document struct {
+	Id      int64
+	Title   string
+	Version int64 `gorm:"version"`
+}
+
 // Scanner
 type NullValue struct {
 	Id      int64
@@ -1,5 +1,5 @@
 dialects=("postgres" "mysql" "sqlite")
 
 for dialect in "${dialects[@]}" ; do
-    GORM_DIALECT=${dialect} go test
+    GORM_DIALECT=${dialect} go test -tags sqlite_json
 done
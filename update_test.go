@@ -419,3 +419,129 @@ func TestUpdateColumnsSkipsAssociations(t *testing.T) {
 		t.Errorf("Expected user's BillingAddress.Address1=%s to remain unchanged after UpdateColumns invocation, but BillingAddress.Address1=%s", address1, freshUser.BillingAddress.Address1)
 	}
 }
+
+func TestUpdateWithVersion(t *testing.T) {
+	DB.DropTable(&Document{})
+	DB.AutoMigrate(&Document{})
+
+	document := Document{Title: "draft"}
+	DB.Save(&document)
+
+	var editor1, editor2 Document
+	DB.First(&editor1, document.Id)
+	DB.First(&editor2, document.Id)
+
+	editor1.Title = "editor1"
+	if err := DB.Save(&editor1).Error; err != nil {
+		t.Errorf("No error should happen when save document with current version, but got %v", err)
+	}
+	if editor1.Version != 1 {
+		t.Errorf("Version should be increased after update, but got %v", editor1.Version)
+	}
+
+	editor2.Title = "editor2"
+	if err := DB.Save(&editor2).Error; err != gorm.ErrStaleObject {
+		t.Errorf("Should return ErrStaleObject when save stale document, but got %v", err)
+	}
+
+	if err := DB.Model(&editor2).Update("title", "editor2 again").Error; err != gorm.ErrStaleObject {
+		t.Errorf("Should return ErrStaleObject when update stale document, but got %v", err)
+	}
+
+	if err := DB.Model(&editor1).Updates(map[string]interface{}{"title": "editor1 again", "version": 100}).Error; err != nil {
+		t.Errorf("No error should happen when update document with current version, but got %v", err)
+	}
+
+	var result Document
+	DB.First(&result, document.Id)
+	if result.Title != "editor1 again" || result.Version != 2 || editor1.Version != 2 {
+		t.Errorf("Document should be updated by editor1 with version 2, but got %#v", result)
+	}
+
+	DB.Model(&Document{}).Where("id = ?", document.Id).Update("title", "batch")
+	DB.First(&result, document.Id)
+	if result.Title != "batch" || result.Version != 3 {
+		t.Errorf("Batch updates should increase version without checking it, but got %#v", result)
+	}
+
+	DB.Model(&result).UpdateColumn("title", "column")
+	DB.First(&result, document.Id)
+	if result.Title != "column" || result.Version != 3 {
+		t.Errorf("UpdateColumn shouldn't check or increase version, but got %#v", result)
+	}
+
+	if result := DB.Model(&editor2).Or("title = ?", "column").Update("title", "editor2 with or"); result.Error != nil || result.RowsAffected != 0 {
+		t.Errorf("Should update nothing without ErrStaleObject when update stale document with Or conditions, but got %v, %v", result.Error, result.RowsAffected)
+	}
+	DB.First(&result, document.Id)
+	if result.Title != "column" || result.Version != 3 {
+		t.Errorf("Or conditions shouldn't bypass the version check, but got %#v", result)
+	}
+
+	if result := DB.Model(&result).Where("title = ?", "unmatched").Update("title", "chained"); result.Error != nil || result.RowsAffected != 0 {
+		t.Errorf("Should update nothing without ErrStaleObject when chained conditions don't match, but got %v, %v", result.Error, result.RowsAffected)
+	}
+	if DB.First(&result, document.Id); result.Title != "column" || result.Version != 3 {
+		t.Errorf("Document shouldn't be updated when chained conditions don't match, but got %#v", result)
+	}
+}
+
+type LegacyRecord struct {
+	Id         int64
+	Name       string
+	InsertedOn int64      `gorm:"autoCreateTime"`
+	ModifiedOn int64      `gorm:"autoUpdateTime:milli"`
+	TouchedOn  int64      `gorm:"autoUpdateTime:nano"`
+	ChangedAt  *time.Time `gorm:"autoUpdateTime"`
+}
+
+func TestAutoTimeFields(t *testing.T) {
+	DB.DropTable(&LegacyRecord{})
+	DB.AutoMigrate(&LegacyRecord{})
+
+	before := time.Now()
+	record := LegacyRecord{Name: "legacy"}
+	DB.Save(&record)
+
+	if record.InsertedOn < before.Unix() || record.InsertedOn > time.Now().Unix() {
+		t.Errorf("autoCreateTime field should be set to unix seconds, but got %v", record.InsertedOn)
+	}
+	if record.ModifiedOn < before.UnixNano()/int64(time.Millisecond) || record.TouchedOn < before.UnixNano() {
+		t.Errorf("autoUpdateTime fields should be set when create, but got %v, %v", record.ModifiedOn, record.TouchedOn)
+	}
+	if record.ChangedAt == nil || record.ChangedAt.Before(before) {
+		t.Errorf("autoUpdateTime time field should be set when create, but got %v", record.ChangedAt)
+	}
+
+	var created LegacyRecord
+	DB.First(&created, record.Id)
+	if created.InsertedOn != record.InsertedOn || created.TouchedOn != record.TouchedOn {
+		t.Errorf("Auto time fields should be saved, but got %#v", created)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	DB.Model(&record).Update("name", "updated")
+	var updated LegacyRecord
+	DB.First(&updated, record.Id)
+	if updated.InsertedOn != created.InsertedOn {
+		t.Errorf("autoCreateTime field shouldn't be changed when update")
+	}
+	if updated.ModifiedOn <= created.ModifiedOn || updated.TouchedOn <= created.TouchedOn || !updated.ChangedAt.After(*created.ChangedAt) {
+		t.Errorf("autoUpdateTime fields should be updated, but got %#v", updated)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	DB.Model(LegacyRecord{}).Where("id = ?", record.Id).Updates(map[string]interface{}{"name": "batch"})
+	var batchUpdated LegacyRecord
+	DB.First(&batchUpdated, record.Id)
+	if batchUpdated.Name != "batch" || batchUpdated.TouchedOn <= updated.TouchedOn {
+		t.Errorf("autoUpdateTime fields should be updated by batch updates, but got %#v", batchUpdated)
+	}
+
+	DB.Model(&batchUpdated).UpdateColumn("name", "column")
+	var columnUpdated LegacyRecord
+	DB.First(&columnUpdated, record.Id)
+	if columnUpdated.Name != "column" || columnUpdated.TouchedOn != batchUpdated.TouchedOn {
+		t.Errorf("autoUpdateTime fields shouldn't be updated by UpdateColumn, but got %#v", columnUpdated)
+	}
+}
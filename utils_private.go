@@ -18,6 +18,13 @@ func fileWithLineNum() string {
 	return ""
 }
 
+func indirectType(reflectType reflect.Type) reflect.Type {
+	for reflectType.Kind() == reflect.Ptr {
+		reflectType = reflectType.Elem()
+	}
+	return reflectType
+}
+
 func isBlank(value reflect.Value) bool {
 	return reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface())
 }
@@ -96,3 +103,50 @@ func strInSlice(a string, list []string) bool {
 	}
 	return false
 }
+
+// errorField returns the field named fieldName of err or the errors it wraps, it's used to read error codes of database drivers without importing them,
+// so their drivers aren't registered by gorm, and the error types of drivers requiring cgo don't need cgo to build
+func errorField(err error, fieldName string) (reflect.Value, bool) {
+	if err == nil {
+		return reflect.Value{}, false
+	}
+
+	if value := reflect.Indirect(reflect.ValueOf(err)); value.Kind() == reflect.Struct {
+		if field := value.FieldByName(fieldName); field.IsValid() {
+			return field, true
+		}
+	}
+
+	switch wrapper := err.(type) {
+	case interface{ Unwrap() error }:
+		return errorField(wrapper.Unwrap(), fieldName)
+	case interface{ Unwrap() []error }:
+		for _, e := range wrapper.Unwrap() {
+			if field, ok := errorField(e, fieldName); ok {
+				return field, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// errorNumber returns the integer field named fieldName of err or the errors it wraps
+func errorNumber(err error, fieldName string) (int64, bool) {
+	if field, ok := errorField(err, fieldName); ok {
+		switch field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return field.Int(), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return int64(field.Uint()), true
+		}
+	}
+	return 0, false
+}
+
+// errorCode returns the string field named fieldName of err or the errors it wraps
+func errorCode(err error, fieldName string) (string, bool) {
+	if field, ok := errorField(err, fieldName); ok && field.Kind() == reflect.String {
+		return field.String(), true
+	}
+	return "", false
+}